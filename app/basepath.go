@@ -0,0 +1,151 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"gingersnap/app/utils"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Base path
+//
+//
+// ------------------------------------------------------------------
+
+// cleanBasePath normalizes the url path where the site is served,
+// without a trailing slash. The root path is empty.
+//
+// ex: "blog", "/blog/"  =>  "/blog"
+// .
+func cleanBasePath(basePath string) (string, error) {
+	if strings.ContainsAny(basePath, "?#:\\ ") {
+		return "", fmt.Errorf("could not load base path [%s]", basePath)
+	}
+
+	p := path.Clean("/" + basePath)
+	if p == "/" {
+		return "", nil
+	}
+
+	return p, nil
+}
+
+// configBasePath reads the base path from the config file, if it exists.
+// This is used to serve an export, without loading the whole config.
+// .
+func configBasePath(configPath string) (string, error) {
+	if !utils.Exists(configPath) {
+		return "", nil
+	}
+
+	configBytes, err := utils.ReadFile(configPath)
+	if err != nil {
+		return "", err
+	}
+
+	c := struct {
+		BasePath string `json:"basePath"`
+	}{}
+
+	if err := json.Unmarshal(configBytes, &c); err != nil {
+		return "", fmt.Errorf("could not load config [%s]: %w", configPath, err)
+	}
+
+	return cleanBasePath(c.BasePath)
+}
+
+// mountBasePath serves the handler under the base path. The
+// other paths redirect to the base path, ex: "/" => "/blog/".
+//
+// The handler sees the paths without the base path, so its
+// root-relative redirects get the base path added back.
+//
+//	/blog/about  =>  301 /blog/about/
+//
+// .
+func mountBasePath(h http.Handler, basePath string) http.Handler {
+	if basePath == "" {
+		return h
+	}
+
+	stripped := http.StripPrefix(basePath, h)
+
+	r := http.NewServeMux()
+	r.Handle(basePath+"/", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		stripped.ServeHTTP(&basePathWriter{ResponseWriter: w, basePath: basePath}, req)
+	}))
+	r.Handle("/", http.RedirectHandler(basePath+"/", http.StatusFound))
+
+	return r
+}
+
+// basePathWriter adds the base path to the root-relative
+// redirect location of a response, before it is written.
+// .
+type basePathWriter struct {
+	http.ResponseWriter
+	basePath string
+}
+
+func (w *basePathWriter) WriteHeader(status int) {
+	if loc := w.Header().Get("Location"); loc != "" {
+		w.Header().Set("Location", prefixUrl(loc, w.basePath))
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *basePathWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Matches the quoted values of the html attributes with urls.
+var linkPattern = regexp.MustCompile(`(?i)(\s(?:href|src|poster|action|srcset)=)("[^"]*"|'[^']*')`)
+
+// prefixLinks adds the base path to the root-relative urls in the
+// html attributes. This applies to the templates, and to the links
+// and media within the rendered markdown bodies.
+//
+// ex: `<a href="/about/">`  =>  `<a href="/blog/about/">`
+// .
+func prefixLinks(html []byte, basePath string) []byte {
+	return linkPattern.ReplaceAllFunc(html, func(match []byte) []byte {
+		m := linkPattern.FindSubmatch(match)
+		attr, value := string(m[1]), string(m[2])
+
+		quote := value[:1]
+		urls := value[1 : len(value)-1]
+
+		// A srcset has a list of urls, ex: "/a.webp 1x, /b.webp 2x".
+		if strings.EqualFold(strings.TrimSpace(attr), "srcset=") {
+			entries := strings.Split(urls, ",")
+			for i, entry := range entries {
+				url := strings.TrimLeft(entry, " \t\n")
+				entries[i] = entry[:len(entry)-len(url)] + prefixUrl(url, basePath)
+			}
+			urls = strings.Join(entries, ",")
+		} else {
+			urls = prefixUrl(urls, basePath)
+		}
+
+		return []byte(attr + quote + urls + quote)
+	})
+}
+
+// prefixUrl adds the base path to a root-relative url.
+// The absolute and protocol-relative urls are unchanged.
+//
+// ex: "/media/go.webp"  =>  "/blog/media/go.webp"
+// .
+func prefixUrl(url, basePath string) string {
+	if strings.HasPrefix(url, "/") && !strings.HasPrefix(url, "//") {
+		return basePath + url
+	}
+	return url
+}
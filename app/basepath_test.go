@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestSite configures an engine with the starter content,
+// and the config changes, ex: {"basePath": "/blog"}.
+// .
+func newTestSite(t *testing.T, changes map[string]any) *Gingersnap {
+	t.Helper()
+
+	configBytes, err := os.ReadFile("assets/config/gingersnap.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := map[string]any{}
+	if err := json.Unmarshal(configBytes, &c); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range changes {
+		c[k] = v
+	}
+
+	configBytes, err = json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configPath := filepath.Join(t.TempDir(), configName)
+	if err := os.WriteFile(configPath, configBytes, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := &Gingersnap{
+		ConfigPath:     configPath,
+		PostsPath:      "assets/posts",
+		MediaPath:      "assets/media",
+		MessagesPath:   "assets/i18n",
+		ArchetypesPath: "assets/archetypes",
+		Logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	if err := g.Configure(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return g
+}
+
+func TestMountBasePathRedirects(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "about"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "about", "index.html"), []byte("about"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	handlers := map[string]http.Handler{
+		"serve": mountBasePath(&distHandler{root: http.Dir(dir)}, "/blog"),
+		"dev":   newTestSite(t, map[string]any{"basePath": "/blog"}).routes(),
+	}
+
+	tests := []struct {
+		url      string
+		status   int
+		location string
+	}{
+		{"/blog/about", http.StatusMovedPermanently, "/blog/about/"},
+		{"/blog/about?x=1", http.StatusMovedPermanently, "/blog/about/?x=1"},
+		{"/blog/about/", http.StatusOK, ""},
+		{"/blog", http.StatusMovedPermanently, "/blog/"},
+		{"/", http.StatusFound, "/blog/"},
+	}
+
+	for name, h := range handlers {
+		for _, tt := range tests {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+			if w.Code != tt.status {
+				t.Errorf("%s %s: got status %d, want %d", name, tt.url, w.Code, tt.status)
+			}
+			if got := w.Header().Get("Location"); got != tt.location {
+				t.Errorf("%s %s: got location %q, want %q", name, tt.url, got, tt.location)
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Color helpers
+//
+//
+// ------------------------------------------------------------------
+
+// The page backgrounds for the light and dark color schemes.
+// These match the `--nc-bg-1` variables in the "head" template.
+const lightBackground = "#ffffff" // white
+const darkBackground = "#0f172a"  // slate-900
+
+// The minimum WCAG AA contrast ratio for normal text.
+const contrastAA = 4.5
+
+// hexColorPattern matches colors like "#fff" and "#ffffff".
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// isHexColor reports if the given string is a hex color.
+// .
+func isHexColor(s string) bool {
+	return hexColorPattern.MatchString(s)
+}
+
+// contrastRatio computes the WCAG contrast ratio of two hex colors.
+// The result ranges from 1 (no contrast) to 21 (black on white).
+//
+// Ref: https://www.w3.org/TR/WCAG21/#dfn-contrast-ratio
+// .
+func contrastRatio(a, b string) float64 {
+	la := luminance(a)
+	lb := luminance(b)
+
+	if la < lb {
+		la, lb = lb, la
+	}
+
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// luminance computes the relative luminance of a hex color.
+//
+// Ref: https://www.w3.org/TR/WCAG21/#dfn-relative-luminance
+// .
+func luminance(hex string) float64 {
+	h := hex[1:]
+
+	// Expand the short form, ex: "#fff" => "#ffffff".
+	if len(h) == 3 {
+		h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+	}
+
+	channel := func(s string) float64 {
+		v, _ := strconv.ParseUint(s, 16, 8)
+		c := float64(v) / 255
+
+		if c <= 0.03928 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+
+	r := channel(h[0:2])
+	g := channel(h[2:4])
+	b := channel(h[4:6])
+
+	return 0.2126*r + 0.7152*g + 0.0722*b
+}
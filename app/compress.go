@@ -0,0 +1,207 @@
+package app
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Compression
+//
+//
+// ------------------------------------------------------------------
+
+// The default minimum file size for compression, in bytes.
+// Smaller files do not benefit from compression.
+const defaultCompressMinSize = 1024
+
+// compressibleExts is the set of text file extensions which can be compressed.
+// Media files like `.webp` or `.mp4` are already compressed.
+var compressibleExts = map[string]bool{
+	".html": true,
+	".css":  true,
+	".js":   true,
+	".json": true,
+	".xml":  true,
+	".txt":  true,
+	".svg":  true,
+}
+
+// encoding is a content encoding, with the file
+// extension of the precompressed siblings.
+// .
+type encoding struct {
+	name     string
+	ext      string
+	compress func(data []byte, level int) ([]byte, error)
+
+	// The compression level of the precompressed siblings, and the
+	// cheaper level of the responses compressed on the fly.
+	bestLevel int
+	fastLevel int
+}
+
+// encodings lists the supported content encodings, by order of preference.
+var encodings = []encoding{
+	{name: "br", ext: ".br", compress: compressBrotli, bestLevel: brotli.BestCompression, fastLevel: 5},
+	{name: "gzip", ext: ".gz", compress: compressGzip, bestLevel: gzip.BestCompression, fastLevel: gzip.DefaultCompression},
+}
+
+// isCompressible reports if the file path has a text extension.
+// .
+func isCompressible(filePath string) bool {
+	return compressibleExts[path.Ext(filePath)]
+}
+
+// acceptedEncodings returns the supported encodings that are
+// allowed by the Accept-Encoding header, by order of preference.
+//
+// ex: "gzip, deflate, br"  =>  [br, gzip]
+// .
+func acceptedEncodings(header string) []encoding {
+	accepted := make(map[string]bool, 4)
+
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+
+		// Skip encodings which are explicitly refused, ex: "gzip;q=0".
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				continue
+			}
+		}
+
+		accepted[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+
+	ret := make([]encoding, 0, len(encodings))
+	for _, enc := range encodings {
+		if accepted[enc.name] || accepted["*"] {
+			ret = append(ret, enc)
+		}
+	}
+
+	return ret
+}
+
+// compressGzip compresses the data with gzip, at the compression level.
+// .
+func compressGzip(data []byte, level int) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	w, err := gzip.NewWriterLevel(buf, level)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// compressBrotli compresses the data with brotli, at the compression level.
+// .
+func compressBrotli(data []byte, level int) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w := brotli.NewWriterLevel(buf, level)
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ------------------------------------------------------------------
+//
+//
+// Server Middleware
+//
+//
+// ------------------------------------------------------------------
+
+// compressResponseWriter buffers the response, so that
+// it can be compressed once the handler is done.
+// .
+type compressResponseWriter struct {
+	http.ResponseWriter
+	status int
+	buf    bytes.Buffer
+}
+
+func (w *compressResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *compressResponseWriter) Write(b []byte) (int, error) {
+	return w.buf.Write(b)
+}
+
+// compress is a middleware which compresses text responses, with the same
+// rules as the precompressed siblings written by the exporter. This way,
+// the dev server mirrors a production host which serves the siblings.
+// .
+func (g *Gingersnap) compress(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		// Skip media files, which are already compressed.
+		if path.Ext(r.URL.Path) != "" && !isCompressible(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		// Skip clients which do not accept compression.
+		accepted := acceptedEncodings(r.Header.Get("Accept-Encoding"))
+		if len(accepted) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressResponseWriter{
+			ResponseWriter: w,
+			status:         http.StatusOK,
+		}
+
+		next.ServeHTTP(cw, r)
+
+		body := cw.buf.Bytes()
+
+		// Compress successful responses above the size threshold. The
+		// fast level is used, as the best level is too slow on the fly.
+		if cw.status == http.StatusOK && len(body) >= g.config.Export.CompressMinSize {
+			if data, err := accepted[0].compress(body, accepted[0].fastLevel); err == nil {
+				// Set the content type from the uncompressed body, as
+				// net/http does not sniff the compressed responses.
+				if w.Header().Get("Content-Type") == "" {
+					w.Header().Set("Content-Type", http.DetectContentType(body))
+				}
+				w.Header().Set("Content-Encoding", accepted[0].name)
+				w.Header().Del("Content-Length")
+				body = data
+			}
+		}
+
+		w.WriteHeader(cw.status)
+		w.Write(body)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompressContentType(t *testing.T) {
+	g := newTestSite(t, map[string]any{"export": map[string]any{"compress": true}})
+
+	srv := httptest.NewServer(g.routes())
+	defer srv.Close()
+
+	for url, want := range map[string]string{
+		"/":           "text/html",
+		"/styles.css": "text/css",
+	} {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept-Encoding", "gzip")
+
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if ce := resp.Header.Get("Content-Encoding"); ce != "gzip" {
+			t.Errorf("%s: got Content-Encoding %q, want gzip", url, ce)
+		}
+		if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, want) {
+			t.Errorf("%s: got Content-Type %q, want %s", url, ct, want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package app
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -21,7 +22,7 @@ type config struct {
 	Site site `json:"site"`
 
 	// Homepage sections
-	Homepage []string `json:"homepage"`
+	Homepage []homepageSection `json:"homepage"`
 
 	// Anchor links for the navbar
 	NavbarLinks []siteLink `json:"navbarLinks"`
@@ -29,12 +30,45 @@ type config struct {
 	// Anchor links for the footer
 	FooterLinks []siteLink `json:"footerLinks"`
 
+	// The site languages. The first one is the default language.
+	Languages []*language `json:"languages"`
+
+	// The url patterns for posts, pages and categories
+	Permalinks permalinks `json:"permalinks"`
+
+	// The url path where the site is served, ex: "/blog" (default is the root)
+	BasePath string `json:"basePath"`
+
+	// Permanent redirects, from old url paths to new url paths
+	Redirects map[string]string `json:"redirects"`
+
+	// Related posts settings
+	Related relatedConfig `json:"related"`
+
 	// The git repository where the static site will be managed
 	Repository string `json:"repository"`
 
+	// Deploy-specific settings
+	Deploy deployConfig `json:"deploy"`
+
+	// Export-specific settings
+	Export exportConfig `json:"export"`
+
 	// Site styling
 	Theme theme
 
+	// Custom color themes, by name
+	Themes map[string]customTheme `json:"themes"`
+
+	// The resolved custom color themes, by name
+	customThemes map[string]theme
+
+	// Non-fatal issues found while loading the config
+	Warnings []string
+
+	// Syntax highlighting for code blocks
+	Highlight highlight `json:"highlight"`
+
 	// The Analytics Tag for metrics
 	AnalyticsTag string `json:"analyticsTag"`
 
@@ -47,11 +81,22 @@ type config struct {
 
 // newConfig parses the settings and returns a *Config struct.
 // .
-func newConfig(configBytes []byte, debug bool) (*config, error) {
+func newConfig(configBytes []byte, debug bool, listenAddr string) (*config, error) {
+
+	if listenAddr == "" {
+		listenAddr = ":4000"
+	}
 
 	c := &config{
 		Debug:      debug,
-		ListenAddr: ":4000",
+		ListenAddr: listenAddr,
+		Export: exportConfig{
+			Minify:          true,
+			CompressMinSize: defaultCompressMinSize,
+		},
+		Related: relatedConfig{
+			Count: limitRelated,
+		},
 	}
 
 	// Parse the config file.
@@ -77,18 +122,55 @@ func newConfig(configBytes []byte, debug bool) (*config, error) {
 		c.Site.Email = fmt.Sprintf("admin@%s", c.Site.Host)
 	}
 
+	// Serve the site under the base path. The site url includes it,
+	// so that the absolute urls (ex: the sitemap) include it too.
+	basePath, err := cleanBasePath(c.BasePath)
+	if err != nil {
+		return nil, err
+	}
+
+	c.BasePath = basePath
+	c.Site.Url += basePath
+
 	// If no Homepage sections are defined, then create
 	// a default setup with the "$latest" posts only.
 	if c.Homepage == nil {
-		c.Homepage = []string{sectionLatest}
+		c.Homepage = []homepageSection{{Section: sectionLatest}}
+	}
+
+	// Resolve the custom themes.
+	c.customThemes = make(map[string]theme, len(c.Themes))
+
+	for name, ct := range c.Themes {
+		t, warnings, err := ct.resolve(name)
+		if err != nil {
+			return nil, err
+		}
+		c.customThemes[name] = t
+		c.Warnings = append(c.Warnings, warnings...)
 	}
 
+	// Sort the warnings, since the custom themes are stored in a map.
+	sort.Strings(c.Warnings)
+
 	// Retrieve the theme.
-	theme, err := newTheme(c.Site.Theme)
+	theme, err := newTheme(c.Site.Theme.Name, c.customThemes)
 	if err != nil {
 		return nil, err
 	}
 
+	// Retrieve the dark mode. Set appropriate defaults.
+	if c.Site.Theme.DarkMode == "" {
+		c.Site.Theme.DarkMode = darkMode("auto")
+	}
+
+	if m := c.Site.Theme.DarkMode; !m.IsAuto() && !m.IsLight() && !m.IsDark() {
+		return nil, fmt.Errorf("could not load dark mode [%s]", m)
+	}
+
+	theme.DarkMode = c.Site.Theme.DarkMode
+	theme.Toggle = c.Site.Theme.Toggle
+
 	c.Theme = theme
 
 	// Retrieve the display. Set appropriate defaults.
@@ -100,6 +182,42 @@ func newConfig(configBytes []byte, debug bool) (*config, error) {
 		return nil, fmt.Errorf("could not load display [%s]", d)
 	}
 
+	// Retrieve the syntax highlighting styles.
+	if err := c.Highlight.validate(); err != nil {
+		return nil, err
+	}
+
+	// Retrieve the permalink patterns.
+	if err := c.Permalinks.validate(); err != nil {
+		return nil, err
+	}
+
+	// Retrieve the site languages.
+	if err := c.validateLanguages(); err != nil {
+		return nil, err
+	}
+
+	// Retrieve the homepage sections of every language.
+	for _, lang := range c.Languages {
+		for i := range lang.Homepage {
+			if err := lang.Homepage[i].validate(c.Site.Display); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Check the redirect file formats.
+	for _, name := range c.Export.RedirectFormats {
+		if _, ok := redirectFormats[name]; !ok {
+			return nil, fmt.Errorf("could not load redirect format [%s]", name)
+		}
+	}
+
+	// Retrieve the deploy target.
+	if err := c.Deploy.validate(c.Repository); err != nil {
+		return nil, err
+	}
+
 	return c, nil
 }
 
@@ -122,8 +240,73 @@ type site struct {
 	Url         string
 	Email       string
 	Image       image
-	Theme       string  `json:"theme"`
-	Display     display `json:"display"`
+	Theme       siteTheme `json:"theme"`
+	Display     display   `json:"display"`
+}
+
+// ------------------------------------------------------------------
+//
+//
+// Type: siteTheme
+//
+//
+// ------------------------------------------------------------------
+
+// siteTheme stores the theme settings for the site.
+//
+// It can be given as a theme name, or as an object
+// with the dark mode settings.
+//
+// ex:
+//
+//	"theme": "pink"
+//
+//	"theme": {"name": "pink", "darkMode": "auto", "darkToggle": true}
+//
+// .
+type siteTheme struct {
+	Name     string   `json:"name"`
+	DarkMode darkMode `json:"darkMode"`
+	Toggle   bool     `json:"darkToggle"`
+}
+
+// UnmarshalJSON parses the theme from a string or an object.
+// .
+func (t *siteTheme) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		t.Name = name
+		return nil
+	}
+
+	// Use an alias type to avoid recursing into this method.
+	type siteThemeAlias siteTheme
+
+	return json.Unmarshal(data, (*siteThemeAlias)(t))
+}
+
+// ------------------------------------------------------------------
+//
+//
+// Type: exportConfig
+//
+//
+// ------------------------------------------------------------------
+
+// exportConfig stores export-specific settings.
+// .
+type exportConfig struct {
+	// If the HTML, CSS and XML files are minified (default true)
+	Minify bool `json:"minify"`
+
+	// If gzip and brotli siblings are written for the text files (default false)
+	Compress bool `json:"compress"`
+
+	// The minimum file size for compression, in bytes (default 1024)
+	CompressMinSize int `json:"compressMinSize"`
+
+	// The redirect files to write: "netlify" or "nginx"
+	RedirectFormats []string `json:"redirectFormats"`
 }
 
 // ------------------------------------------------------------------
@@ -166,6 +349,37 @@ func (d display) IsList() bool {
 	return d == "list"
 }
 
+// ------------------------------------------------------------------
+//
+//
+// Type: darkMode
+//
+//
+// ------------------------------------------------------------------
+
+// darkMode describes when the dark color scheme
+// is applied across the website.
+// .
+type darkMode string
+
+// IsAuto reports if the dark mode follows the user's system preference.
+// .
+func (d darkMode) IsAuto() bool {
+	return d == "auto"
+}
+
+// IsLight reports if the site is always rendered in light mode.
+// .
+func (d darkMode) IsLight() bool {
+	return d == "light"
+}
+
+// IsDark reports if the site is always rendered in dark mode.
+// .
+func (d darkMode) IsDark() bool {
+	return d == "dark"
+}
+
 // ------------------------------------------------------------------
 //
 //
@@ -175,28 +389,40 @@ func (d display) IsList() bool {
 // ------------------------------------------------------------------
 
 // theme represents a simple color profile for the site.
+// Each color has a counterpart for the dark color scheme.
 // .
 type theme struct {
 	Heading   string
 	Primary   string
 	Secondary string
 	Link      string
+
+	DarkPrimary   string
+	DarkSecondary string
+	DarkLink      string
+
+	// When the dark color scheme is applied
+	DarkMode darkMode
+
+	// If the reader can toggle the color scheme
+	Toggle bool
 }
 
-// newTheme retrieves the requested theme.
+// newTheme retrieves the requested theme, from the
+// built-in themes or from the given custom themes.
 // Simplified themes can also be requested. A simplified theme
 // modifies the secondary color, which makes it less colorful.
 //
 // ex:
 //
 //	// will retrieve the pink theme
-//	newTheme("pink")
+//	newTheme("pink", nil)
 //
 //	// will retrieve the pink theme and modify it
-//	newTheme("pink-simple")
+//	newTheme("pink-simple", nil)
 //
 // .
-func newTheme(themeStr string) (theme, error) {
+func newTheme(themeStr string, custom map[string]theme) (theme, error) {
 
 	// If no theme is given, then return the default.
 	if themeStr == "" {
@@ -209,18 +435,27 @@ func newTheme(themeStr string) (theme, error) {
 	themeStr, isSimple := strings.CutSuffix(themeStr, "-simple")
 
 	// Retrieve the theme.
-	if t, ok := themes[themeStr]; ok {
+	t, ok := themes[themeStr]
+	if !ok {
+		t, ok = custom[themeStr]
+	}
+
+	if ok {
 
 		theme := theme{
-			Primary:   t.Primary,
-			Secondary: t.Secondary,
-			Link:      t.Link,
+			Primary:       t.Primary,
+			Secondary:     t.Secondary,
+			Link:          t.Link,
+			DarkPrimary:   t.DarkPrimary,
+			DarkSecondary: t.DarkSecondary,
+			DarkLink:      t.DarkLink,
 		}
 
 		// If a "simple" theme is requested,
-		// then change the secondary color to black.
+		// then change the secondary color to black (white in dark mode).
 		if isSimple {
-			theme.Secondary = "#0f172a" // slate-900
+			theme.Secondary = "#0f172a"     // slate-900
+			theme.DarkSecondary = "#f1f5f9" // slate-100
 		}
 
 		return theme, nil
@@ -229,6 +464,181 @@ func newTheme(themeStr string) (theme, error) {
 	return theme{}, fmt.Errorf("Could not load theme [%s]", themeStr)
 }
 
+// ------------------------------------------------------------------
+//
+//
+// Type: customTheme
+//
+//
+// ------------------------------------------------------------------
+
+// customTheme stores a color theme defined in the config.
+// Colors that are not given are taken from the extended
+// built-in theme, or from the default theme.
+// .
+type customTheme struct {
+	Extends       string `json:"extends"`
+	Primary       string `json:"primary"`
+	Secondary     string `json:"secondary"`
+	Link          string `json:"link"`
+	DarkPrimary   string `json:"darkPrimary"`
+	DarkSecondary string `json:"darkSecondary"`
+	DarkLink      string `json:"darkLink"`
+}
+
+// resolve builds the theme from the custom colors.
+// An error is returned for malformed colors, and a warning is
+// returned for each color that does not meet the WCAG AA contrast
+// ratio against the site background.
+// .
+func (ct customTheme) resolve(name string) (theme, []string, error) {
+
+	// Custom themes cannot replace the built-in themes.
+	if _, ok := themes[name]; ok {
+		return theme{}, nil, fmt.Errorf("custom theme [%s] collides with a built-in theme", name)
+	}
+
+	// Retrieve the base theme.
+	t := defaultTheme
+	if ct.Extends != "" {
+		base, ok := themes[ct.Extends]
+		if !ok {
+			return theme{}, nil, fmt.Errorf("custom theme [%s] extends unknown theme [%s]", name, ct.Extends)
+		}
+		t = base
+	}
+
+	colors := []struct {
+		label      string
+		value      string
+		dst        *string
+		background string
+	}{
+		{"primary", ct.Primary, &t.Primary, lightBackground},
+		{"secondary", ct.Secondary, &t.Secondary, lightBackground},
+		{"link", ct.Link, &t.Link, lightBackground},
+		{"darkPrimary", ct.DarkPrimary, &t.DarkPrimary, darkBackground},
+		{"darkSecondary", ct.DarkSecondary, &t.DarkSecondary, darkBackground},
+		{"darkLink", ct.DarkLink, &t.DarkLink, darkBackground},
+	}
+
+	warnings := make([]string, 0, len(colors))
+
+	for _, c := range colors {
+		// Keep the base color, if none is given.
+		if c.value == "" {
+			continue
+		}
+
+		if !isHexColor(c.value) {
+			return theme{}, nil, fmt.Errorf("custom theme [%s] has invalid %s color [%s]", name, c.label, c.value)
+		}
+
+		*c.dst = c.value
+
+		if ratio := contrastRatio(c.value, c.background); ratio < contrastAA {
+			warnings = append(warnings, fmt.Sprintf(
+				"custom theme [%s] %s color [%s] has a contrast ratio of %.2f:1 against [%s], below WCAG AA (%.1f:1)",
+				name, c.label, c.value, ratio, c.background, contrastAA,
+			))
+		}
+	}
+
+	return t, warnings, nil
+}
+
+// ------------------------------------------------------------------
+//
+//
+// Type: themePreview
+//
+//
+// ------------------------------------------------------------------
+
+// themePreview describes a color theme, and the contrast of
+// each color against the site background. It is used by the
+// theme preview page and the `themes` command.
+// .
+type themePreview struct {
+	Name    string
+	Custom  bool
+	Extends string
+	Light   []themeSwatch
+	Dark    []themeSwatch
+}
+
+// themeSwatch describes a single theme color.
+// .
+type themeSwatch struct {
+	Label      string
+	Color      string
+	Background string
+	Contrast   string
+	PassesAA   bool
+}
+
+// newThemeSwatch constructs a themeSwatch for the color
+// against the given background.
+// .
+func newThemeSwatch(label, color, background string) themeSwatch {
+	ratio := contrastRatio(color, background)
+
+	return themeSwatch{
+		Label:      label,
+		Color:      color,
+		Background: background,
+		Contrast:   fmt.Sprintf("%.2f:1", ratio),
+		PassesAA:   ratio >= contrastAA,
+	}
+}
+
+// themePreviews returns the previews for all built-in themes,
+// followed by the custom themes, sorted by name.
+// .
+func (c *config) themePreviews() []themePreview {
+	previews := make([]themePreview, 0, len(themes)+len(c.customThemes))
+
+	build := func(name string, t theme) themePreview {
+		return themePreview{
+			Name: name,
+			Light: []themeSwatch{
+				newThemeSwatch("Primary", t.Primary, lightBackground),
+				newThemeSwatch("Secondary", t.Secondary, lightBackground),
+				newThemeSwatch("Link", t.Link, lightBackground),
+			},
+			Dark: []themeSwatch{
+				newThemeSwatch("Primary", t.DarkPrimary, darkBackground),
+				newThemeSwatch("Secondary", t.DarkSecondary, darkBackground),
+				newThemeSwatch("Link", t.DarkLink, darkBackground),
+			},
+		}
+	}
+
+	for _, name := range sortedKeys(themes) {
+		previews = append(previews, build(name, themes[name]))
+	}
+
+	for _, name := range sortedKeys(c.customThemes) {
+		p := build(name, c.customThemes[name])
+		p.Custom = true
+		p.Extends = c.Themes[name].Extends
+		previews = append(previews, p)
+	}
+
+	return previews
+}
+
+// sortedKeys returns the keys of the map in sorted order.
+// .
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // ------------------------------------------------------------------
 //
 //
@@ -242,6 +652,10 @@ var defaultTheme = theme{
 	Primary:   "#4338ca", // indigo-700
 	Secondary: "#0f172a", // slate-900
 	Link:      "#1d4ed8", // blue-700
+
+	DarkPrimary:   "#818cf8", // indigo-400
+	DarkSecondary: "#f1f5f9", // slate-100
+	DarkLink:      "#60a5fa", // blue-400
 }
 
 // Color themes for the site.
@@ -250,30 +664,54 @@ var themes = map[string]theme{
 		Primary:   "#4f46e5", // indigo-600
 		Secondary: "#4338ca", // indigo-700
 		Link:      "#2563eb", // blue-600
+
+		DarkPrimary:   "#818cf8", // indigo-400
+		DarkSecondary: "#a5b4fc", // indigo-300
+		DarkLink:      "#60a5fa", // blue-400
 	},
 	"green": {
 		Primary:   "#0f766e", // teal-700
 		Secondary: "#0f766e", // teal-700
 		Link:      "#0369a1", // sky-700
+
+		DarkPrimary:   "#2dd4bf", // teal-400
+		DarkSecondary: "#2dd4bf", // teal-400
+		DarkLink:      "#38bdf8", // sky-400
 	},
 	"pink": {
 		Primary:   "#db2777", // pink-600
 		Secondary: "#be185d", // pink-700
 		Link:      "#4f46e5", // indigo-600
+
+		DarkPrimary:   "#f472b6", // pink-400
+		DarkSecondary: "#f9a8d4", // pink-300
+		DarkLink:      "#818cf8", // indigo-400
 	},
 	"blue": {
 		Primary:   "#0284c7", // sky-600
 		Secondary: "#0284c7", // sky-600
 		Link:      "#2563eb", // blue-600
+
+		DarkPrimary:   "#38bdf8", // sky-400
+		DarkSecondary: "#38bdf8", // sky-400
+		DarkLink:      "#60a5fa", // blue-400
 	},
 	"red": {
 		Primary:   "#b91c1c", // red-700
 		Secondary: "#be123c", // rose-700
 		Link:      "#4f46e5", // indigo-600
+
+		DarkPrimary:   "#f87171", // red-400
+		DarkSecondary: "#fb7185", // rose-400
+		DarkLink:      "#818cf8", // indigo-400
 	},
 	"black": {
 		Primary:   "#0f172a", // slate-900
 		Secondary: "#0f172a", // slate-900
 		Link:      "#2563eb", // blue-600
+
+		DarkPrimary:   "#f1f5f9", // slate-100
+		DarkSecondary: "#f1f5f9", // slate-100
+		DarkLink:      "#60a5fa", // blue-400
 	},
 }
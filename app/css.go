@@ -0,0 +1,296 @@
+package app
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// CSS pipeline
+//
+//
+// ------------------------------------------------------------------
+
+// classAttrPattern matches the class attribute of HTML elements.
+var classAttrPattern = regexp.MustCompile(`class="([^"]*)"`)
+
+// classSelectorPattern matches the class names within a CSS selector.
+// Class names can contain escaped characters, ex: `.sm\:grid-cols-2`.
+var classSelectorPattern = regexp.MustCompile(`\.((?:\\.|[a-zA-Z0-9_-])+)`)
+
+// collectClasses adds the class names used in the HTML document to the set.
+// .
+func collectClasses(html []byte, classes map[string]bool) {
+	for _, match := range classAttrPattern.FindAllSubmatch(html, -1) {
+		for _, class := range strings.Fields(string(match[1])) {
+			classes[class] = true
+		}
+	}
+}
+
+// purgeCSS removes the rules from the stylesheet whose selectors
+// reference classes that are not in the given set of used classes.
+//
+// Rules without class selectors (ex: `body`, `:root`, `*`) are always kept.
+// Grouping at-rules (ex: `@media`) are purged recursively, and dropped
+// if they become empty. Other at-rules (ex: `@font-face`) are kept as-is.
+// .
+func purgeCSS(css []byte, classes map[string]bool) []byte {
+	buf := new(bytes.Buffer)
+
+	// Keep the license comments.
+	buf.WriteString(licenseComments(string(css)))
+
+	for _, r := range splitCSSRules(string(css)) {
+
+		// Keep statements, like `@import` or `@charset`.
+		if strings.HasSuffix(r.prelude, ";") {
+			buf.WriteString(r.prelude)
+			continue
+		}
+
+		// Purge grouping at-rules recursively.
+		if strings.HasPrefix(r.prelude, "@media") || strings.HasPrefix(r.prelude, "@supports") {
+			inner := purgeCSS([]byte(r.body), classes)
+			if len(inner) > 0 {
+				buf.WriteString(r.prelude)
+				buf.WriteString("{")
+				buf.Write(inner)
+				buf.WriteString("}")
+			}
+			continue
+		}
+
+		// Keep all other at-rules.
+		if strings.HasPrefix(r.prelude, "@") {
+			buf.WriteString(r.prelude)
+			buf.WriteString("{")
+			buf.WriteString(r.body)
+			buf.WriteString("}")
+			continue
+		}
+
+		// Keep the selectors which only reference used classes.
+		selectors := make([]string, 0, 4)
+		for _, sel := range splitTopLevel(r.prelude, ',') {
+			if selectorIsUsed(sel, classes) {
+				selectors = append(selectors, sel)
+			}
+		}
+
+		if len(selectors) > 0 {
+			buf.WriteString(strings.Join(selectors, ","))
+			buf.WriteString("{")
+			buf.WriteString(r.body)
+			buf.WriteString("}")
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// selectorIsUsed reports if every class referenced
+// by the selector is in the set of used classes.
+// .
+func selectorIsUsed(selector string, classes map[string]bool) bool {
+	for _, match := range classSelectorPattern.FindAllStringSubmatch(selector, -1) {
+		if !classes[unescapeCSS(match[1])] {
+			return false
+		}
+	}
+	return true
+}
+
+// unescapeCSS removes the backslash escapes from a CSS identifier.
+// The code point escapes end with an optional space.
+//
+// ex: `sm\:grid-cols-2`  =>  `sm:grid-cols-2`, `\32xl\:p-4`  =>  `2xl:p-4`
+// .
+func unescapeCSS(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		// Read the hex digits of a code point escape.
+		end := i + 1
+		for end < len(s) && end < i+7 && isHexDigit(s[end]) {
+			end++
+		}
+
+		if end == i+1 {
+			b.WriteByte(s[end])
+			i = end
+			continue
+		}
+
+		r, _ := strconv.ParseUint(s[i+1:end], 16, 32)
+		b.WriteRune(rune(r))
+
+		if end < len(s) && s[end] == ' ' {
+			end++
+		}
+		i = end - 1
+	}
+
+	return b.String()
+}
+
+func isHexDigit(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// ------------------------------------------------------------------
+//
+//
+// Type: cssRule
+//
+//
+// ------------------------------------------------------------------
+
+// cssRule is a top-level rule of a stylesheet.
+// The prelude is the selector or at-rule, and the body
+// is the content between the outer braces.
+// .
+type cssRule struct {
+	prelude string
+	body    string
+}
+
+// splitCSSRules splits the stylesheet into its top-level rules.
+// Comments are removed, and strings are respected when matching braces.
+// .
+func splitCSSRules(css string) []cssRule {
+	css = stripCSSComments(css)
+	rules := make([]cssRule, 0, 64)
+
+	start := 0
+	depth := 0
+	bodyStart := 0
+	var quote rune
+
+	for i, r := range css {
+		if quote != 0 {
+			if r == quote && (i == 0 || css[i-1] != '\\') {
+				quote = 0
+			}
+			continue
+		}
+
+		switch r {
+		case '"', '\'':
+			quote = r
+
+		case ';':
+			// A statement at-rule, ex: `@charset "UTF-8";`
+			if depth == 0 {
+				if prelude := strings.TrimSpace(css[start : i+1]); prelude != "" {
+					rules = append(rules, cssRule{prelude: prelude})
+				}
+				start = i + 1
+			}
+
+		case '{':
+			if depth == 0 {
+				bodyStart = i + 1
+			}
+			depth++
+
+		case '}':
+			depth--
+			if depth == 0 {
+				rules = append(rules, cssRule{
+					prelude: strings.TrimSpace(css[start : bodyStart-1]),
+					body:    css[bodyStart:i],
+				})
+				start = i + 1
+			}
+		}
+	}
+
+	return rules
+}
+
+// splitTopLevel splits the string by the separator,
+// ignoring separators nested in parentheses or brackets.
+// .
+func splitTopLevel(s string, sep rune) []string {
+	parts := make([]string, 0, 4)
+	depth := 0
+	start := 0
+
+	for i, r := range s {
+		switch r {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+// stripCSSComments removes all `/* ... */` comments from the stylesheet.
+// .
+func stripCSSComments(css string) string {
+	var b strings.Builder
+
+	for {
+		i := strings.Index(css, "/*")
+		if i < 0 {
+			break
+		}
+
+		end := strings.Index(css[i+2:], "*/")
+		if end < 0 {
+			break
+		}
+
+		b.WriteString(css[:i])
+		css = css[i+2+end+2:]
+	}
+
+	b.WriteString(css)
+	return b.String()
+}
+
+// licenseComments returns the license comments (starting
+// with `/*!`) from the stylesheet.
+// .
+func licenseComments(css string) string {
+	var b strings.Builder
+
+	for {
+		i := strings.Index(css, "/*!")
+		if i < 0 {
+			break
+		}
+
+		end := strings.Index(css[i+3:], "*/")
+		if end < 0 {
+			break
+		}
+
+		b.WriteString(css[i : i+3+end+2])
+		css = css[i+3+end+2:]
+	}
+
+	return b.String()
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPurgeStylesheet(t *testing.T) {
+	styles, err := assets.ReadFile("assets/css/styles.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	classes := map[string]bool{}
+	collectClasses([]byte(`<div class="p-5 w-1/3 text-rose-600 sm:grid-cols-2">`), classes)
+
+	css := string(purgeCSS(styles, classes))
+
+	for _, rule := range []string{
+		`.p-5{padding:1.25rem}`,
+		`.w-1\/3{width:33.333333%}`,
+		`.text-rose-600{`,
+		`@media (min-width:640px){.sm\:grid-cols-2{grid-template-columns:repeat(2,minmax(0,1fr))}}`,
+	} {
+		if !strings.Contains(css, rule) {
+			t.Errorf("missing rule %s", rule)
+		}
+	}
+
+	// The base styles are kept, and the unused classes are removed.
+	if !strings.Contains(css, "box-sizing:border-box") {
+		t.Error("missing the base styles")
+	}
+	if strings.Contains(css, ".p-4{") || strings.Contains(css, "@media (min-width:768px)") {
+		t.Error("unused classes were not removed")
+	}
+}
+
+func TestUnescapeCSS(t *testing.T) {
+	for in, want := range map[string]string{
+		`sm\:grid-cols-2`: "sm:grid-cols-2",
+		`w-1\/3`:          "w-1/3",
+		`\32xl\:p-4`:      "2xl:p-4",
+		`\32 0`:           "20",
+	} {
+		if got := unescapeCSS(in); got != want {
+			t.Errorf("unescapeCSS(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
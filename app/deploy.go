@@ -0,0 +1,408 @@
+package app
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gingersnap/app/utils"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Type: deployConfig
+//
+//
+// ------------------------------------------------------------------
+
+// deployConfig stores the deploy settings.
+//
+// ex:
+//
+//	"deploy": {
+//		"target": "git",
+//		"git": {"repository": "/path/to/static/repo", "branch": "gh-pages"}
+//	}
+//
+// .
+type deployConfig struct {
+	// The deploy target: "git", "dir" or "s3"
+	Target string `json:"target"`
+
+	// The settings for each deploy target
+	Git *gitTarget `json:"git"`
+	Dir *dirTarget `json:"dir"`
+	S3  *s3Target  `json:"s3"`
+}
+
+// validate sets the default target settings, and
+// checks that the selected target is configured.
+//
+// The legacy `repository` setting is used as the git
+// target, if no deploy target is given.
+// .
+func (d *deployConfig) validate(repository string) error {
+	if d.Target == "" && repository != "" {
+		d.Target = "git"
+	}
+
+	if d.Target == "git" && d.Git == nil {
+		d.Git = &gitTarget{Repository: repository}
+	}
+
+	switch d.Target {
+	case "":
+		return nil
+	case "git":
+		if d.Git != nil {
+			return d.Git.validate()
+		}
+	case "dir":
+		if d.Dir != nil {
+			return d.Dir.validate()
+		}
+	case "s3":
+		if d.S3 != nil {
+			return d.S3.validate()
+		}
+	default:
+		return fmt.Errorf("could not load deploy target [%s]", d.Target)
+	}
+
+	return fmt.Errorf("could not load deploy target [%s]: missing \"%s\" settings", d.Target, d.Target)
+}
+
+// setPath replaces the path of the dir or git target, with
+// the path resolved from the project root, if it is set.
+// .
+func (d *deployConfig) setPath(p string) {
+	if p == "" {
+		return
+	}
+
+	switch d.Target {
+	case "git":
+		d.Git.Repository = p
+	case "dir":
+		d.Dir.Path = p
+	}
+}
+
+// target returns the selected deploy target.
+// .
+func (d *deployConfig) target() (deployTarget, error) {
+	switch d.Target {
+	case "git":
+		return d.Git, nil
+	case "dir":
+		return d.Dir, nil
+	case "s3":
+		return d.S3, nil
+	}
+
+	return nil, fmt.Errorf("no deploy target configured")
+}
+
+// ------------------------------------------------------------------
+//
+//
+// Type: deployTarget
+//
+//
+// ------------------------------------------------------------------
+
+// deployTarget is a destination where the exported site is published.
+//
+// A deploy works in three steps: the target is checked, the deployed
+// files are listed and compared with the exported files, and the
+// resulting diff is applied to the target.
+// .
+type deployTarget interface {
+	// String describes the target, for the deploy summary.
+	String() string
+
+	// check verifies that the target can be deployed to,
+	// from the project root and the export directory.
+	check(root, exportPath string) error
+
+	// list returns the deployed files, as a map of
+	// slash-separated paths to their md5 hashes.
+	list() (map[string]string, error)
+
+	// apply uploads and removes the files in the diff.
+	// The source files are read from the export directory.
+	apply(srcDir string, d *deployDiff) error
+}
+
+// deployPreserver is implemented by the deploy targets
+// which keep some deployed files that are not in the export.
+// .
+type deployPreserver interface {
+	preserves(p string) bool
+}
+
+// Deploy publishes the exported site to the configured deploy target.
+// The diff is written to w. In a dry run, the target is left untouched.
+// .
+func (g *Gingersnap) Deploy(w io.Writer, dryRun bool) error {
+	target, err := g.config.Deploy.target()
+	if err != nil {
+		return err
+	}
+
+	if err := target.check(filepath.Dir(g.ConfigPath), g.ExportPath); err != nil {
+		return err
+	}
+
+	local, err := hashDir(g.ExportPath)
+	if err != nil {
+		return err
+	}
+
+	remote, err := target.list()
+	if err != nil {
+		return err
+	}
+
+	d := newDeployDiff(local, remote)
+
+	// Keep the preserved files.
+	if pr, ok := target.(deployPreserver); ok {
+		d.preserve(pr.preserves)
+	}
+
+	// Skip the build manifest, if nothing else changed.
+	d.trimManifest()
+
+	fmt.Fprintf(w, "Deploy target: %s\n\n", target)
+	d.write(w)
+
+	if dryRun || d.empty() {
+		return nil
+	}
+
+	return target.apply(g.ExportPath, d)
+}
+
+// ------------------------------------------------------------------
+//
+//
+// Type: deployDiff
+//
+//
+// ------------------------------------------------------------------
+
+// deployDiff stores the changes between the exported
+// files and the deployed files, as sorted paths.
+// .
+type deployDiff struct {
+	added   []string
+	changed []string
+	removed []string
+}
+
+// newDeployDiff compares the local and remote files by their hashes.
+// .
+func newDeployDiff(local, remote map[string]string) *deployDiff {
+	d := &deployDiff{}
+
+	for _, p := range sortedKeys(local) {
+		hash, ok := remote[p]
+		if !ok {
+			d.added = append(d.added, p)
+		} else if hash != local[p] {
+			d.changed = append(d.changed, p)
+		}
+	}
+
+	for _, p := range sortedKeys(remote) {
+		if _, ok := local[p]; !ok {
+			d.removed = append(d.removed, p)
+		}
+	}
+
+	return d
+}
+
+// preserve drops the preserved paths from the removed files.
+// .
+func (d *deployDiff) preserve(preserves func(string) bool) {
+	removed := d.removed[:0]
+	for _, p := range d.removed {
+		if !preserves(p) {
+			removed = append(removed, p)
+		}
+	}
+	d.removed = removed
+}
+
+// trimManifest drops the build manifest if it is the only changed file.
+// Its build time changes on every export, so it is only deployed
+// along with other changes.
+// .
+func (d *deployDiff) trimManifest() {
+	if len(d.added) == 0 && len(d.removed) == 0 && len(d.changed) == 1 && d.changed[0] == buildManifestName {
+		d.changed = nil
+	}
+}
+
+// empty reports if there are no changes to deploy.
+// .
+func (d *deployDiff) empty() bool {
+	return len(d.added) == 0 && len(d.changed) == 0 && len(d.removed) == 0
+}
+
+// uploads returns the added and changed paths.
+// .
+func (d *deployDiff) uploads() []string {
+	return append(append([]string{}, d.added...), d.changed...)
+}
+
+// write writes the changes, one file per line, and a summary.
+// Added files are prefixed with `+`, changed files with `~`,
+// and removed files with `-`.
+// .
+func (d *deployDiff) write(w io.Writer) {
+	for _, p := range d.added {
+		fmt.Fprintf(w, "  + %s\n", p)
+	}
+	for _, p := range d.changed {
+		fmt.Fprintf(w, "  ~ %s\n", p)
+	}
+	for _, p := range d.removed {
+		fmt.Fprintf(w, "  - %s\n", p)
+	}
+
+	if d.empty() {
+		fmt.Fprintln(w, "No changes to deploy.")
+		return
+	}
+
+	fmt.Fprintf(w, "\n%d added, %d changed, %d removed\n", len(d.added), len(d.changed), len(d.removed))
+}
+
+// ------------------------------------------------------------------
+//
+//
+// Deploy helpers
+//
+//
+// ------------------------------------------------------------------
+
+// hashDir returns the files in the directory, as a map of
+// slash-separated paths to their md5 hashes. The `.git` directory
+// is skipped, but the `.gingersnap` build manifest is deployed.
+//
+// The md5 hash matches the ETag of objects uploaded to S3.
+// .
+func hashDir(dir string) (map[string]string, error) {
+	files := make(map[string]string, 64)
+
+	if !utils.Exists(dir) {
+		return files, nil
+	}
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.Name() == ".git" && d.IsDir() {
+			return filepath.SkipDir
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		data, err := utils.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		sum := md5.Sum(data)
+		files[filepath.ToSlash(rel)] = hex.EncodeToString(sum[:])
+		return nil
+	})
+
+	return files, err
+}
+
+// syncDir copies the uploaded files from the source directory,
+// and removes the deleted files from the destination directory.
+// .
+func syncDir(srcDir, dstDir string, d *deployDiff) error {
+	for _, p := range d.uploads() {
+		data, err := utils.ReadFile(filepath.Join(srcDir, filepath.FromSlash(p)))
+		if err != nil {
+			return err
+		}
+
+		if err := utils.WriteFile(filepath.Join(dstDir, filepath.FromSlash(p)), data); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range d.removed {
+		if err := removeFile(dstDir, p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeFile removes the file from the root directory,
+// along with any parent directories left empty.
+// .
+func removeFile(root, p string) error {
+	if err := os.Remove(filepath.Join(root, filepath.FromSlash(p))); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for dir := filepath.Dir(filepath.FromSlash(p)); dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		entries, err := os.ReadDir(filepath.Join(root, dir))
+		if err != nil || len(entries) > 0 {
+			break
+		}
+
+		if err := os.Remove(filepath.Join(root, dir)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// samePath reports if both paths resolve to the same absolute path.
+// .
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+
+	return errA == nil && errB == nil && absA == absB
+}
+
+// isWithin reports if the path is the directory, or is inside it.
+// .
+func isWithin(p, dir string) bool {
+	absP, errP := filepath.Abs(p)
+	absDir, errDir := filepath.Abs(dir)
+	if errP != nil || errDir != nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(absDir, absP)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"fmt"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Type: dirTarget
+//
+//
+// ------------------------------------------------------------------
+
+// dirTarget syncs the site to a local directory, like `rsync --delete`.
+// Files which are not in the export are removed from the directory.
+// .
+type dirTarget struct {
+	// The path of the destination directory
+	Path string `json:"path"`
+}
+
+func (t *dirTarget) validate() error {
+	if t.Path == "" {
+		return fmt.Errorf("could not load deploy target [dir]: missing path")
+	}
+	return nil
+}
+
+func (t *dirTarget) String() string {
+	return fmt.Sprintf("dir %s", t.Path)
+}
+
+// check verifies that the destination does not contain the project
+// or the export, and is not inside the export. Otherwise, the sync
+// would remove the project files, or the exported files.
+// .
+func (t *dirTarget) check(root, exportPath string) error {
+	if isWithin(root, t.Path) {
+		return fmt.Errorf("cannot deploy the site to the project directory or its parents, please specify another path")
+	}
+
+	if isWithin(exportPath, t.Path) || isWithin(t.Path, exportPath) {
+		return fmt.Errorf("cannot deploy the site to the export directory, its parents or its subdirectories, please specify another path")
+	}
+
+	return nil
+}
+
+func (t *dirTarget) list() (map[string]string, error) {
+	return hashDir(t.Path)
+}
+
+func (t *dirTarget) apply(srcDir string, d *deployDiff) error {
+	return syncDir(srcDir, t.Path, d)
+}
@@ -0,0 +1,249 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	textTmp "text/template"
+	"time"
+
+	"gingersnap/app/utils"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Type: gitTarget
+//
+//
+// ------------------------------------------------------------------
+
+// gitTarget deploys the site to a local clone of a git repository,
+// then commits and pushes the changes to the remote branch.
+// .
+type gitTarget struct {
+	// The path of the local repository
+	Repository string `json:"repository"`
+
+	// The remote and branch to push to (default "origin" and "main")
+	Remote string `json:"remote"`
+	Branch string `json:"branch"`
+
+	// If the push is forced (default false)
+	Force bool `json:"force"`
+
+	// The commit message template (default "Updated site on {{.Date}}")
+	Message string `json:"message"`
+
+	// The repository paths which are never removed, like a custom
+	// README or CI workflows. A trailing slash matches a directory,
+	// and globs are supported, ex: "README*"
+	Preserve []string `json:"preserve"`
+
+	// The parsed commit message template
+	message *textTmp.Template
+}
+
+// The default git target settings.
+const defaultGitRemote = "origin"
+const defaultGitBranch = "main"
+const defaultGitMessage = "Updated site on {{.Date}}"
+
+// The repository paths which are preserved by default.
+var defaultGitPreserve = []string{"README*", "LICENSE*", ".github/", ".gitignore", ".nojekyll"}
+
+// gitMessageData is the data for the commit message template.
+// .
+type gitMessageData struct {
+	Date    string
+	Added   int
+	Changed int
+	Removed int
+}
+
+// validate sets the default settings, and parses the commit message template.
+// .
+func (t *gitTarget) validate() error {
+	if t.Repository == "" {
+		return fmt.Errorf("could not load deploy target [git]: missing repository")
+	}
+
+	if t.Remote == "" {
+		t.Remote = defaultGitRemote
+	}
+
+	if t.Branch == "" {
+		t.Branch = defaultGitBranch
+	}
+
+	if t.Message == "" {
+		t.Message = defaultGitMessage
+	}
+
+	if t.Preserve == nil {
+		t.Preserve = defaultGitPreserve
+	}
+
+	for _, pattern := range t.Preserve {
+		if _, err := path.Match(strings.TrimSuffix(pattern, "/"), ""); err != nil {
+			return fmt.Errorf("could not load deploy target [git]: preserve [%s]: %w", pattern, err)
+		}
+	}
+
+	tmpl, err := textTmp.New("message").Parse(t.Message)
+	if err != nil {
+		return fmt.Errorf("could not load deploy target [git]: %w", err)
+	}
+	t.message = tmpl
+
+	return nil
+}
+
+func (t *gitTarget) String() string {
+	return fmt.Sprintf("git %s (%s/%s)", t.Repository, t.Remote, t.Branch)
+}
+
+// check verifies that the repository exists, that it is not the
+// project directory, that it has no uncommitted changes, and
+// that it is not behind the remote branch.
+// .
+func (t *gitTarget) check(root, exportPath string) error {
+	if samePath(root, t.Repository) {
+		return fmt.Errorf("cannot deploy the site to the project directory, please specify another git repository")
+	}
+
+	if !utils.Exists(t.Repository) {
+		return fmt.Errorf("dir %s does not exist", t.Repository)
+	}
+
+	if !utils.Exists(filepath.Join(t.Repository, ".git")) {
+		return fmt.Errorf("dir %s is not a git repository", t.Repository)
+	}
+
+	// Check for uncommitted changes.
+	status, err := t.git("status", "--porcelain")
+	if err != nil {
+		return err
+	}
+
+	if status != "" {
+		return fmt.Errorf("repository %s has uncommitted changes, please commit or stash them first:\n%s", t.Repository, status)
+	}
+
+	// Check that the remote branch exists. It does not
+	// exist yet for the first deploy to a new branch.
+	heads, err := t.git("ls-remote", "--heads", t.Remote, t.Branch)
+	if err != nil {
+		return err
+	}
+
+	if heads == "" {
+		return nil
+	}
+
+	// Check that the repository is not behind the remote branch.
+	if _, err := t.git("fetch", "--quiet", t.Remote, t.Branch); err != nil {
+		return err
+	}
+
+	if _, err := t.git("rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		return fmt.Errorf("repository %s has no commits, but %s/%s exists, please pull it first", t.Repository, t.Remote, t.Branch)
+	}
+
+	behind, err := t.git("rev-list", "--count", "HEAD..FETCH_HEAD")
+	if err != nil {
+		return err
+	}
+
+	if behind != "0" {
+		return fmt.Errorf("repository %s is %s commit(s) behind %s/%s, please pull it first", t.Repository, behind, t.Remote, t.Branch)
+	}
+
+	return nil
+}
+
+// preserves reports if the repository path matches the preserve list.
+// .
+func (t *gitTarget) preserves(p string) bool {
+	for _, pattern := range t.Preserve {
+		if dir, ok := strings.CutSuffix(pattern, "/"); ok {
+			if p == dir || strings.HasPrefix(p, dir+"/") {
+				return true
+			}
+			continue
+		}
+
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *gitTarget) list() (map[string]string, error) {
+	return hashDir(t.Repository)
+}
+
+// apply syncs the repository with the export directory,
+// then commits and pushes the changes.
+// .
+func (t *gitTarget) apply(srcDir string, d *deployDiff) error {
+	if err := syncDir(srcDir, t.Repository, d); err != nil {
+		return err
+	}
+
+	msg := new(bytes.Buffer)
+	data := gitMessageData{
+		Date:    time.Now().Format(time.UnixDate),
+		Added:   len(d.added),
+		Changed: len(d.changed),
+		Removed: len(d.removed),
+	}
+
+	if err := t.message.Execute(msg, data); err != nil {
+		return fmt.Errorf("commit message: %w", err)
+	}
+
+	if _, err := t.git("add", "-A"); err != nil {
+		return err
+	}
+
+	if _, err := t.git("commit", "--quiet", "-m", msg.String()); err != nil {
+		return err
+	}
+
+	push := []string{"push", t.Remote, fmt.Sprintf("HEAD:%s", t.Branch)}
+	if t.Force {
+		push = append(push, "--force")
+	}
+
+	_, err := t.git(push...)
+	return err
+}
+
+// git runs a git command in the repository, and returns its trimmed output.
+// If the command fails, the error includes the command's stderr.
+// .
+func (t *gitTarget) git(args ...string) (string, error) {
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = t.Repository
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = strings.TrimSpace(stdout.String())
+		}
+		return "", fmt.Errorf("git %s: %w\n%s", strings.Join(args, " "), err, msg)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
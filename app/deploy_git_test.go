@@ -0,0 +1,199 @@
+package app
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitTestEnv isolates the git commands from the user's git config.
+// .
+func gitTestEnv(t *testing.T) {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "gingersnap")
+	t.Setenv("GIT_AUTHOR_EMAIL", "gingersnap@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "gingersnap")
+	t.Setenv("GIT_COMMITTER_EMAIL", "gingersnap@example.com")
+}
+
+// runGit runs a git command in the directory, and returns its trimmed output.
+// .
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	out, err := (&gitTarget{Repository: dir}).git(args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// writeFiles writes the files into the directory, by slash-separated path.
+// .
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for p, data := range files {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(p)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(p)), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGitDeploy(t *testing.T) {
+	gitTestEnv(t)
+
+	// The remote has a README, which is preserved, and a stale page.
+	tmp := t.TempDir()
+	remote := filepath.Join(tmp, "remote.git")
+	seed := filepath.Join(tmp, "seed")
+	repo := filepath.Join(tmp, "repo")
+	dir := filepath.Join(tmp, "dist")
+
+	runGit(t, tmp, "init", "--quiet", "--bare", "--initial-branch=main", remote)
+	runGit(t, tmp, "clone", "--quiet", remote, seed)
+	writeFiles(t, seed, map[string]string{"README.md": "# Site", "old.html": "<h1>Old</h1>"})
+	runGit(t, seed, "add", "-A")
+	runGit(t, seed, "commit", "--quiet", "-m", "Initial commit")
+	runGit(t, seed, "push", "--quiet", "origin", "HEAD:main")
+	runGit(t, tmp, "clone", "--quiet", remote, repo)
+
+	target := &gitTarget{Repository: repo}
+	if err := target.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	g := &Gingersnap{
+		ExportPath: dir,
+		config:     &config{Deploy: deployConfig{Target: "git", Git: target}},
+	}
+
+	remoteFiles := func() string {
+		return strings.ReplaceAll(runGit(t, remote, "ls-tree", "-r", "--name-only", "main"), "\n", ",")
+	}
+	remoteCommits := func() string {
+		return runGit(t, remote, "rev-list", "--count", "main")
+	}
+
+	// The deploy commits and pushes the export, and keeps the README.
+	writeFiles(t, dir, map[string]string{"index.html": "<h1>Home</h1>", buildManifestName: `{"buildTime":"1"}`})
+
+	if err := g.Deploy(io.Discard, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := remoteFiles(), ".gingersnap,README.md,index.html"; got != want {
+		t.Errorf("got remote files %s, want %s", got, want)
+	}
+	if got := remoteCommits(); got != "2" {
+		t.Errorf("got %s remote commits, want 2", got)
+	}
+
+	// A new export of the same content is not deployed.
+	writeFiles(t, dir, map[string]string{buildManifestName: `{"buildTime":"2"}`})
+
+	var out bytes.Buffer
+	if err := g.Deploy(&out, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), "No changes to deploy.") {
+		t.Errorf("got deploy output\n%s\nwant no changes", out.String())
+	}
+	if got := remoteCommits(); got != "2" {
+		t.Errorf("got %s remote commits, want 2", got)
+	}
+
+	// A repository with uncommitted changes is refused.
+	writeFiles(t, repo, map[string]string{"draft.html": "<h1>Draft</h1>"})
+
+	if err := g.Deploy(io.Discard, false); err == nil || !strings.Contains(err.Error(), "uncommitted changes") {
+		t.Errorf("got error %v, want uncommitted changes", err)
+	}
+	os.Remove(filepath.Join(repo, "draft.html"))
+
+	// A repository behind its remote is refused.
+	runGit(t, seed, "pull", "--quiet", "origin", "main")
+	writeFiles(t, seed, map[string]string{"CNAME": "example.com"})
+	runGit(t, seed, "add", "-A")
+	runGit(t, seed, "commit", "--quiet", "-m", "Add CNAME")
+	runGit(t, seed, "push", "--quiet", "origin", "HEAD:main")
+
+	if err := g.Deploy(io.Discard, false); err == nil || !strings.Contains(err.Error(), "behind") {
+		t.Errorf("got error %v, want behind the remote", err)
+	}
+
+	// The push is not forced by default, so a diverged
+	// remote rejects it, with the stderr of git.
+	apply := func(html string) error {
+		writeFiles(t, dir, map[string]string{"index.html": html})
+
+		local, err := hashDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		deployed, err := target.list()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		d := newDeployDiff(local, deployed)
+		d.preserve(target.preserves)
+		return target.apply(dir, d)
+	}
+
+	if err := apply("<h1>New home</h1>"); err == nil || !strings.Contains(err.Error(), "[rejected]") {
+		t.Errorf("got error %v, want the rejected push", err)
+	}
+	if got := remoteCommits(); got != "3" {
+		t.Errorf("got %s remote commits, want 3", got)
+	}
+
+	// A forced push replaces the remote branch.
+	target.Force = true
+	if err := apply("<h1>Newer home</h1>"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := remoteFiles(), ".gingersnap,README.md,index.html"; got != want {
+		t.Errorf("got remote files %s, want %s", got, want)
+	}
+	if got := runGit(t, remote, "show", "main:index.html"); got != "<h1>Newer home</h1>" {
+		t.Errorf("got remote index.html %q", got)
+	}
+}
+
+func TestGitPreserves(t *testing.T) {
+	target := &gitTarget{Repository: "repo"}
+	if err := target.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for p, want := range map[string]bool{
+		"README.md":                true,
+		"LICENSE":                  true,
+		".github/workflows/ci.yml": true,
+		".nojekyll":                true,
+		"docs/README.md":           false,
+		"index.html":               false,
+		".githubx":                 false,
+	} {
+		if got := target.preserves(p); got != want {
+			t.Errorf("%s: got preserved %v, want %v", p, got, want)
+		}
+	}
+}
@@ -0,0 +1,426 @@
+package app
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gingersnap/app/utils"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Type: s3Target
+//
+//
+// ------------------------------------------------------------------
+
+// s3Target uploads the site to an S3-compatible object store,
+// like AWS S3, Cloudflare R2 or MinIO. Requests use path-style
+// urls, and are signed with AWS Signature Version 4.
+//
+// The credentials are read from the `AWS_ACCESS_KEY_ID`,
+// `AWS_SECRET_ACCESS_KEY` and `AWS_SESSION_TOKEN` env vars,
+// so that they are never stored in the config file.
+// .
+type s3Target struct {
+	// The object store url (default "https://s3.<region>.amazonaws.com")
+	Endpoint string `json:"endpoint"`
+
+	// The bucket name
+	Bucket string `json:"bucket"`
+
+	// The bucket region (default "us-east-1")
+	Region string `json:"region"`
+
+	// The key prefix for the uploaded objects, ex: "blog/"
+	Prefix string `json:"prefix"`
+
+	// The credentials, from the env vars
+	accessKey    string
+	secretKey    string
+	sessionToken string
+
+	// The http client for the object store
+	client *http.Client
+}
+
+// The default s3 target region.
+const defaultS3Region = "us-east-1"
+
+// validate sets the default settings.
+// .
+func (t *s3Target) validate() error {
+	if t.Bucket == "" {
+		return fmt.Errorf("could not load deploy target [s3]: missing bucket")
+	}
+
+	if t.Region == "" {
+		t.Region = defaultS3Region
+	}
+
+	if t.Endpoint == "" {
+		t.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", t.Region)
+	}
+
+	if _, err := url.Parse(t.Endpoint); err != nil {
+		return fmt.Errorf("could not load deploy target [s3]: %w", err)
+	}
+
+	if t.Prefix != "" {
+		t.Prefix = strings.Trim(t.Prefix, "/") + "/"
+	}
+
+	t.client = &http.Client{Timeout: time.Minute}
+
+	return nil
+}
+
+func (t *s3Target) String() string {
+	return fmt.Sprintf("s3 %s/%s/%s", strings.TrimSuffix(t.Endpoint, "/"), t.Bucket, t.Prefix)
+}
+
+// check reads the credentials from the env vars.
+// .
+func (t *s3Target) check(root, exportPath string) error {
+	t.accessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+	t.secretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	t.sessionToken = os.Getenv("AWS_SESSION_TOKEN")
+
+	if t.accessKey == "" || t.secretKey == "" {
+		return fmt.Errorf("please set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY to deploy to s3")
+	}
+
+	return nil
+}
+
+// s3ListResult is the response body of the ListObjectsV2 request.
+// .
+type s3ListResult struct {
+	Contents []struct {
+		Key  string `xml:"Key"`
+		ETag string `xml:"ETag"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// list lists the objects under the prefix. The ETag of an object
+// uploaded in a single request is the md5 hash of its content.
+// .
+func (t *s3Target) list() (map[string]string, error) {
+	files := make(map[string]string, 64)
+	token := ""
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", t.Prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		body, err := t.do(http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result s3ListResult
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("s3 list: %w", err)
+		}
+
+		for _, obj := range result.Contents {
+			files[strings.TrimPrefix(obj.Key, t.Prefix)] = strings.Trim(obj.ETag, `"`)
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return files, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// apply uploads the added and changed files, then deletes the removed files.
+// .
+func (t *s3Target) apply(srcDir string, d *deployDiff) error {
+	for _, p := range d.uploads() {
+		data, err := utils.ReadFile(filepath.Join(srcDir, filepath.FromSlash(p)))
+		if err != nil {
+			return err
+		}
+
+		header := http.Header{}
+		header.Set("Content-Type", distContentType(p))
+
+		if _, err := t.do(http.MethodPut, t.Prefix+p, nil, header, data); err != nil {
+			return err
+		}
+	}
+
+	// Delete the removed files, in batches.
+	for start := 0; start < len(d.removed); start += s3DeleteBatch {
+		end := min(start+s3DeleteBatch, len(d.removed))
+		if err := t.delete(d.removed[start:end]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// The maximum number of keys in a DeleteObjects request.
+const s3DeleteBatch = 1000
+
+// s3Delete is the request body of the DeleteObjects request.
+// .
+type s3Delete struct {
+	XMLName xml.Name        `xml:"Delete"`
+	Objects []s3DeleteEntry `xml:"Object"`
+	Quiet   bool            `xml:"Quiet"`
+}
+
+type s3DeleteEntry struct {
+	Key string `xml:"Key"`
+}
+
+// s3DeleteResult is the response body of the DeleteObjects request.
+// In quiet mode, only the keys which failed are listed.
+// .
+type s3DeleteResult struct {
+	Errors []struct {
+		Key     string `xml:"Key"`
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	} `xml:"Error"`
+}
+
+// delete deletes the files in a single DeleteObjects request.
+// .
+func (t *s3Target) delete(paths []string) error {
+	req := s3Delete{Quiet: true}
+	for _, p := range paths {
+		req.Objects = append(req.Objects, s3DeleteEntry{Key: t.Prefix + p})
+	}
+
+	body, err := xml.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	// The md5 checksum is required for the DeleteObjects request.
+	sum := md5.Sum(body)
+	header := http.Header{}
+	header.Set("Content-Type", "application/xml")
+	header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+
+	query := url.Values{}
+	query.Set("delete", "")
+
+	respBody, err := t.do(http.MethodPost, "", query, header, body)
+	if err != nil {
+		return err
+	}
+
+	var result s3DeleteResult
+	if err := xml.Unmarshal(respBody, &result); err != nil {
+		return fmt.Errorf("s3 delete: %w", err)
+	}
+
+	if len(result.Errors) > 0 {
+		e := result.Errors[0]
+		return fmt.Errorf("s3 delete: %d files were not deleted, ex: /%s: %s (%s)", len(result.Errors), e.Key, e.Message, e.Code)
+	}
+
+	return nil
+}
+
+// s3Error is the response body of a failed request.
+// .
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// do sends a signed request for the object key, or
+// for the bucket if the key is empty, and returns the
+// response body. Unsuccessful responses return an error.
+// .
+func (t *s3Target) do(method, key string, query url.Values, header http.Header, body []byte) ([]byte, error) {
+	u, err := url.Parse(strings.TrimSuffix(t.Endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	u.Path = path.Join(u.Path, "/", t.Bucket, "/", key)
+	u.RawPath = s3Escape(u.Path, false)
+	u.RawQuery = s3Query(query)
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	t.sign(req, body, time.Now().UTC())
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 %s %s: %w", method, key, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("s3 %s %s: %w", method, key, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e s3Error
+		if xml.Unmarshal(respBody, &e) == nil && e.Code != "" {
+			return nil, fmt.Errorf("s3 %s /%s: %s: %s (%s)", method, key, resp.Status, e.Message, e.Code)
+		}
+		return nil, fmt.Errorf("s3 %s /%s: %s", method, key, resp.Status)
+	}
+
+	return respBody, nil
+}
+
+// sign adds the AWS Signature Version 4 headers to the request.
+//
+// Ref: https://docs.aws.amazon.com/IAM/latest/UserGuide/create-signed-request.html
+// .
+func (t *s3Target) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if t.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", t.sessionToken)
+	}
+
+	// [1/3] Build the canonical request ------------------
+
+	signed := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		if k := strings.ToLower(k); strings.HasPrefix(k, "x-amz-") || k == "content-type" {
+			signed[k] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+
+	names := make([]string, 0, len(signed))
+	for k := range signed {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	canonicalHeaders := new(strings.Builder)
+	for _, k := range names {
+		fmt.Fprintf(canonicalHeaders, "%s:%s\n", k, signed[k])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	// [2/3] Build the string to sign ---------------------
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, t.Region)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	// [3/3] Sign the string with the derived key ---------
+
+	key := hmacSHA256([]byte("AWS4"+t.secretKey), date)
+	key = hmacSHA256(key, t.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		t.accessKey, scope, signedHeaders, signature,
+	))
+}
+
+// s3Query returns the canonical query string, sorted by key.
+// .
+func s3Query(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, s3Escape(k, true)+"="+s3Escape(v, true))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// s3Escape percent-encodes every character except the unreserved
+// characters. Slashes are kept in paths, but encoded in query values.
+// .
+func s3Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gingersnap/app/utils"
+)
+
+func TestDirTargetCheck(t *testing.T) {
+	root := t.TempDir()
+	exportPath := filepath.Join(root, "dist")
+
+	tests := []struct {
+		path string
+		ok   bool
+	}{
+		{root, false},
+		{filepath.Dir(root), false},
+		{"/", false},
+		{exportPath, false},
+		{filepath.Join(exportPath, "site"), false},
+		{filepath.Join(root, "public"), true},
+		{filepath.Join(root, "distribution"), true},
+		{filepath.Join(filepath.Dir(root), "site"), true},
+	}
+
+	for _, tt := range tests {
+		err := (&dirTarget{Path: tt.path}).check(root, exportPath)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: got error %v, want ok %v", tt.path, err, tt.ok)
+		}
+	}
+}
+
+func TestHashDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, p := range []string{"index.html", buildManifestName, ".git/HEAD", "media/go.webp"} {
+		if err := utils.WriteFile(filepath.Join(dir, p), []byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := hashDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := strings.Join(sortedKeys(files), ",")
+	if want := ".gingersnap,index.html,media/go.webp"; got != want {
+		t.Errorf("got files %s, want %s", got, want)
+	}
+}
+
+func TestDeployDiffManifest(t *testing.T) {
+	remote := map[string]string{"index.html": "a", buildManifestName: "b"}
+
+	// The manifest alone is not deployed.
+	d := newDeployDiff(map[string]string{"index.html": "a", buildManifestName: "c"}, remote)
+	d.trimManifest()
+	if !d.empty() {
+		t.Errorf("got changes %v, want none", d.changed)
+	}
+
+	// The manifest is deployed with the other changes.
+	d = newDeployDiff(map[string]string{"index.html": "d", buildManifestName: "c"}, remote)
+	d.trimManifest()
+	if got := strings.Join(d.changed, ","); got != ".gingersnap,index.html" {
+		t.Errorf("got changes %s, want .gingersnap,index.html", got)
+	}
+}
@@ -0,0 +1,276 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Type: distHandler
+//
+//
+// ------------------------------------------------------------------
+
+// distHandler serves an exported site with the same
+// path semantics as GitHub Pages.
+//
+//	/                =>  /index.html
+//	/some-post/      =>  /some-post/index.html
+//	/some-post       =>  301 to /some-post/
+//	/about           =>  /about.html (if it exists)
+//	/missing/        =>  /404.html, with a 404 status
+//	/CNAME           =>  404
+//
+// Precompressed `.br` and `.gz` siblings are served
+// when the request's Accept-Encoding allows it, and the
+// fingerprinted assets are cached for a year.
+// .
+type distHandler struct {
+	root http.Dir
+
+	// The fingerprinted urls, from the asset manifest
+	fingerprinted map[string]bool
+}
+
+// ServeExport serves the exported site from the given directory, so that
+// the export can be inspected before it is deployed. The server stops
+// when the context is canceled.
+// .
+func (g *Gingersnap) ServeExport(ctx context.Context, dir, addr string) error {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("could not find export dir [%s]", dir)
+	}
+
+	g.logger = g.newLogger()
+
+	// Serve the export under the config's base path, if any.
+	basePath, err := configBasePath(g.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	fingerprinted, err := readAssetManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	h := &distHandler{
+		root:          http.Dir(dir),
+		fingerprinted: fingerprinted,
+	}
+
+	srv := &http.Server{
+		Addr:     addr,
+		Handler:  g.logRequest(g.secureHeaders(mountBasePath(h, basePath))),
+		ErrorLog: slog.NewLogLogger(g.logger.Handler(), slog.LevelError),
+	}
+
+	g.logger.Info("serving export", "dir", dir, "addr", addr, "basePath", basePath)
+	return serve(ctx, srv, g.logger)
+}
+
+func (h *distHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	p := path.Clean("/" + r.URL.Path)
+
+	// The CNAME file only configures the custom domain, and
+	// dotfiles (ex: `.gingersnap`) are not published.
+	if p == "/CNAME" || strings.HasPrefix(path.Base(p), ".") {
+		h.notFound(w, r)
+		return
+	}
+
+	info, err := h.stat(p)
+
+	switch {
+	case err == nil && info.IsDir():
+		// Directories must be requested with a trailing slash.
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			http.Redirect(w, r, redirectUrl(r, p+"/"), http.StatusMovedPermanently)
+			return
+		}
+
+		if _, err := h.stat(path.Join(p, "index.html")); err != nil {
+			h.notFound(w, r)
+			return
+		}
+
+		h.serveFile(w, r, path.Join(p, "index.html"), http.StatusOK)
+
+	case err == nil:
+		h.serveFile(w, r, p, http.StatusOK)
+
+	default:
+		// Extensionless urls can resolve to an HTML file.
+		if path.Ext(p) == "" {
+			if _, err := h.stat(p + ".html"); err == nil {
+				h.serveFile(w, r, p+".html", http.StatusOK)
+				return
+			}
+		}
+
+		h.notFound(w, r)
+	}
+}
+
+// notFound serves the exported 404.html page, with a 404 status.
+// .
+func (h *distHandler) notFound(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.stat("/404.html"); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	h.serveFile(w, r, "/404.html", http.StatusNotFound)
+}
+
+// serveFile writes the file, or its precompressed sibling
+// if the client accepts the encoding.
+// .
+func (h *distHandler) serveFile(w http.ResponseWriter, r *http.Request, name string, status int) {
+	w.Header().Set("Content-Type", distContentType(name))
+
+	if h.fingerprinted[name] {
+		w.Header().Set("Cache-Control", immutableCacheControl)
+	}
+
+	filePath := name
+
+	if isCompressible(name) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		for _, enc := range acceptedEncodings(r.Header.Get("Accept-Encoding")) {
+			if _, err := h.stat(name + enc.ext); err == nil {
+				w.Header().Set("Content-Encoding", enc.name)
+				filePath = name + enc.ext
+				break
+			}
+		}
+	}
+
+	f, err := h.root.Open(filePath)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	// The 404 page is written directly, because http.ServeContent
+	// always responds with a 200 status, or a conditional status.
+	if status != http.StatusOK {
+		w.Header().Set("Content-Length", fmt.Sprint(info.Size()))
+		w.WriteHeader(status)
+		if r.Method != http.MethodHead {
+			io.Copy(w, f)
+		}
+		return
+	}
+
+	http.ServeContent(w, r, name, info.ModTime(), f)
+}
+
+// stat returns the file info for the given path in the export directory.
+// .
+func (h *distHandler) stat(name string) (os.FileInfo, error) {
+	f, err := h.root.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return f.Stat()
+}
+
+// readAssetManifest returns the fingerprinted urls, from the
+// asset manifest of the export. An export without an asset
+// manifest has no fingerprinted urls.
+// .
+func readAssetManifest(dir string) (map[string]bool, error) {
+	data, err := os.ReadFile(filepath.Join(dir, assetManifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := map[string]string{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("could not load asset manifest [%s]: %w", assetManifestName, err)
+	}
+
+	fingerprinted := make(map[string]bool, len(manifest))
+	for _, url := range manifest {
+		fingerprinted[url] = true
+	}
+
+	return fingerprinted, nil
+}
+
+// distContentType returns the content type for the file,
+// based on its extension.
+// .
+func distContentType(name string) string {
+	ext := path.Ext(name)
+
+	if ct, ok := contentTypes[ext]; ok {
+		return ct
+	}
+
+	if ct, ok := distContentTypes[ext]; ok {
+		return ct
+	}
+
+	if ct := mime.TypeByExtension(ext); ct != "" {
+		return ct
+	}
+
+	return "application/octet-stream"
+}
+
+// distContentTypes maps the extensions of exported files which
+// are not always known by the system's MIME type database.
+var distContentTypes = map[string]string{
+	".html": "text/html; charset=utf-8",
+	".js":   "text/javascript; charset=utf-8",
+	".json": "application/json",
+	".svg":  "image/svg+xml",
+	".webp": "image/webp",
+	".mp3":  "audio/mpeg",
+	".mp4":  "video/mp4",
+	"":      "text/plain; charset=utf-8",
+}
+
+// redirectUrl returns the redirect location for the
+// given path, keeping the request's query string.
+// .
+func redirectUrl(r *http.Request, p string) string {
+	if r.URL.RawQuery != "" {
+		return p + "?" + r.URL.RawQuery
+	}
+	return p
+}
@@ -1,11 +1,17 @@
 package app
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"log/slog"
+	"maps"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -33,17 +39,64 @@ type exporter struct {
 
 	// The directory where the site will be exported to.
 	outputPath string
+
+	// The temporary sibling of the output path, where the files are
+	// written. It replaces the output path when the export succeeds.
+	buildPath string
+
+	// The gingersnap version and the content directory, for the build manifest.
+	version     string
+	contentPath string
+
+	// The site url, used to find absolute asset references.
+	siteUrl  string
+	basePath string
+
+	// The fingerprinted urls of the stylesheets, which the
+	// rendered pages link to, by url.
+	assetUrls map[string]string
+
+	// If the HTML, CSS and XML files are minified.
+	minify bool
+
+	// If compressed siblings are written for the text files,
+	// and the minimum file size for compression.
+	compress        bool
+	compressMinSize int
+
+	// The redirects, and the redirect files to write.
+	redirects       []redirect
+	redirectFormats []string
+
+	// The logger for the export progress.
+	logger *slog.Logger
+
+	// The route type of each url, and the stats for the rendered routes.
+	routeTypes map[string]string
+	stats      *buildStats
 }
 
 // newExporter constructs and returns an *exporter
 // with the server's handlers, urls and export dir.
 // .
-func (g *Gingersnap) newExporter() (*exporter, error) {
+func (g *Gingersnap) newExporter(outputPath string) (*exporter, error) {
 
 	// [1/2] Collect the urls to export -------------------
 
 	urls := make([]string, 0, max(len(g.store.posts), 20))
-	urls = append(urls, "/", "/styles.css", "/sitemap/", "/sitemap.xml", "/robots.txt", "/CNAME", "/404/")
+	routeTypes := make(map[string]string, cap(urls))
+
+	// add collects the urls, with their route type for the stats.
+	add := func(routeType string, routes ...string) {
+		for _, route := range routes {
+			urls = append(urls, route)
+			routeTypes[route] = routeType
+		}
+	}
+
+	add(routeAsset, "/styles.css", "/highlight.css")
+	add(routeSitemap, "/sitemap.xml")
+	add(routeOther, "/robots.txt", "/CNAME", "/404/")
 
 	// For "/media/", we read media files
 	// directly from the filesystem.
@@ -63,69 +116,214 @@ func (g *Gingersnap) newExporter() (*exporter, error) {
 	// Build routes for all media files.
 	for _, file := range files {
 		if name := file.Name(); !strings.HasPrefix(name, ".") {
-			urls = append(urls, fmt.Sprintf("/media/%s", name))
+			add(routeMedia, fmt.Sprintf("/media/%s", name))
 		}
 	}
 
-	// Build routes for all blog posts.
-	for _, post := range g.store.posts {
-		urls = append(urls, post.Route())
-	}
+	for _, lang := range g.config.Languages {
+		s := g.stores[lang.Code]
+
+		// Build routes for the homepage and sitemaps.
+		add(routeHome, lang.homeRoute())
+		add(routeSitemap, lang.Prefix+"/sitemap/")
+
+		if g.config.isMultilingual() {
+			add(routeSitemap, lang.sitemapRoute())
+		}
+
+		// Build routes for all blog posts.
+		for _, post := range s.posts {
+			add(routePost, post.Route())
+		}
+
+		// Build routes for all standalone posts (pages).
+		for _, post := range s.pages {
+			add(routePage, post.Route())
+		}
+
+		// Build routes for all categories.
+		for _, cat := range s.categories {
+			add(routeCategory, cat.Route())
+		}
+
+		// Build routes for the archive, by year and month.
+		add(routeArchive, archiveRoute(lang.Prefix))
+
+		for _, y := range s.archive {
+			add(routeArchive, y.Route())
 
-	// Build routes for all standalone posts (pages).
-	for _, post := range g.store.pages {
-		urls = append(urls, post.Route())
+			for _, m := range y.Months {
+				add(routeArchive, m.Route())
+			}
+		}
 	}
 
-	// Build routes for all categories.
-	for _, cat := range g.store.categories {
-		urls = append(urls, cat.Route())
+	// Build routes for all redirects.
+	for _, rd := range g.store.redirects {
+		add(routeRedirect, rd.Route())
 	}
 
 	// [2/2] Construct the exporter -----------------------
 
 	return &exporter{
-		handler:    g.routes(),
+		handler:    g.recoverPanic(g.router()),
 		urls:       urls,
-		outputPath: g.ExportPath,
+		outputPath: filepath.Clean(outputPath),
+		siteUrl:    g.config.Site.Url,
+		basePath:   g.config.BasePath,
+		assetUrls:  g.assetUrls,
+		minify:     g.config.Export.Minify,
+		logger:     g.logger,
+		routeTypes: routeTypes,
+		stats:      g.stats,
+
+		version:     g.Version,
+		contentPath: filepath.Dir(g.ConfigPath),
+
+		compress:        g.config.Export.Compress,
+		compressMinSize: g.config.Export.CompressMinSize,
+
+		redirects:       g.store.redirects,
+		redirectFormats: g.config.Export.RedirectFormats,
 	}, nil
 }
 
 // export exports the configured server routes as a static site.
+// The output path is only replaced when every file is written, so a
+// failed or canceled export keeps the previous export.
 // .
-func (e *exporter) export() error {
-	// Remove the output path, if it exists.
-	if err := os.RemoveAll(e.outputPath); err != nil {
+func (e *exporter) export(ctx context.Context) error {
+	// Create the build directory, next to the output path,
+	// so that it can be renamed into place.
+	if err := utils.EnsurePath(e.outputPath); err != nil {
 		return err
 	}
 
-	// Create the output directory.
-	if err := utils.EnsurePath(e.outputPath); err != nil {
+	buildPath, err := os.MkdirTemp(filepath.Dir(e.outputPath), "."+filepath.Base(e.outputPath)+"-*")
+	if err != nil {
 		return err
 	}
+	defer os.RemoveAll(buildPath)
 
-	// Write the timestamp file.
-	tsPath := filepath.Join(e.outputPath, ".gingersnap")
-	ts := time.Now().Format(time.UnixDate)
-
-	if err := utils.WriteFile(tsPath, []byte(ts)); err != nil {
+	if err := os.Chmod(buildPath, 0755); err != nil {
 		return err
 	}
 
+	e.buildPath = buildPath
+
 	// Render all the paths.
+	start := time.Now()
+	files := make([]*exportFile, 0, len(e.urls))
+
+	e.logger.Info("export started", "dir", e.outputPath, "urls", len(e.urls))
+
+	// The rendered routes, in the order of the files.
+	routes := make([]routeStat, 0, len(e.urls))
+	e.stats.resetRoutes()
+
 	for _, url := range e.urls {
-		if err := e.exportPage(url, e.makePath(url)); err != nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		pageStart := time.Now()
+
+		f, err := e.exportPage(url, e.makePath(url))
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+		routes = append(routes, routeStat{Url: url, Type: e.routeTypes[url], Duration: since(pageStart)})
+
+		msg := "page rendered"
+		if f.isMedia() {
+			msg = "media copied"
+		}
+		e.logger.Debug(msg, "url", url, "size", len(f.body), "duration", time.Since(pageStart))
+	}
+
+	media := countMedia(files)
+	e.logger.Info("pages rendered", "pages", len(files)-media, "media", media, "duration", time.Since(start))
+
+	// Purge the stylesheet.
+	e.processStyles(files)
+
+	// Minify the text files.
+	if e.minify {
+		e.logSummary(e.minifyFiles(files))
+	}
+
+	// Fingerprint the assets.
+	manifest := e.fingerprintAssets(files)
+
+	// Write all the rendered files.
+	for _, f := range files {
+		if err := utils.WriteFile(f.path, f.body); err != nil {
 			return err
 		}
 	}
 
+	// Record the rendered routes, with their final sizes.
+	for i, f := range files {
+		routes[i].Size = len(f.body)
+		e.stats.addRoute(routes[i])
+	}
+	e.stats.sortRoutes()
+
+	// Write the redirect files.
+	for _, name := range e.redirectFormats {
+		format := redirectFormats[name]
+		if err := utils.WriteFile(filepath.Join(e.buildPath, format.fileName), format.build(e.redirects, e.basePath)); err != nil {
+			return err
+		}
+	}
+
+	// Write the compressed siblings.
+	if e.compress {
+		if err := e.compressFiles(files); err != nil {
+			return err
+		}
+	}
+
+	// Write the asset manifest.
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := utils.WriteFile(filepath.Join(e.buildPath, assetManifestName), manifestBytes); err != nil {
+		return err
+	}
+
+	// Write the build manifest.
+	buildBytes, err := json.MarshalIndent(e.buildManifest(files), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := utils.WriteFile(filepath.Join(e.buildPath, buildManifestName), buildBytes); err != nil {
+		return err
+	}
+
+	// Stop before the output path is replaced, if the export is canceled.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Replace the previous export.
+	if err := utils.ReplaceDir(e.buildPath, e.outputPath); err != nil {
+		return err
+	}
+
+	e.logger.Info("export complete", "dir", e.outputPath, "files", len(files), "duration", time.Since(start))
+
 	return nil
 }
 
-// exportPage exports a single page to the filesystem
-// by using the httptest framework to render the page.
+// exportPage renders a single page by using
+// the httptest framework, and returns the rendered file.
 // .
-func (e *exporter) exportPage(url, dstPath string) error {
+func (e *exporter) exportPage(url, dstPath string) (*exportFile, error) {
 	r := httptest.NewRequest(http.MethodGet, url, nil)
 	w := httptest.NewRecorder()
 
@@ -138,23 +336,177 @@ func (e *exporter) exportPage(url, dstPath string) error {
 	// to account for those status codes and skip them.
 
 	if c := w.Result().StatusCode; c != http.StatusOK && c != http.StatusMovedPermanently {
-		return fmt.Errorf("expected URL %s to return %d, but it returned %d instead", url, http.StatusOK, c)
+		return nil, fmt.Errorf("expected URL %s to return %d, but it returned %d instead", url, http.StatusOK, c)
 	}
 
-	// Create the destination directory.
-	if err := utils.EnsurePath(dstPath); err != nil {
-		return err
+	return &exportFile{
+		url:  url,
+		path: dstPath,
+		body: w.Body.Bytes(),
+	}, nil
+}
+
+// processStyles removes the unused rules from the stylesheet,
+// based on the classes found in the rendered HTML files.
+// .
+func (e *exporter) processStyles(files []*exportFile) {
+	var styles *exportFile
+
+	// Collect the classes used in the HTML files.
+	classes := make(map[string]bool, 256)
+
+	for _, f := range files {
+		if f.url == "/styles.css" {
+			styles = f
+		}
+		if f.isHTML() {
+			collectClasses(f.body, classes)
+		}
 	}
 
-	// Write the contents of the response body.
-	if err := utils.WriteFile(dstPath, w.Body.Bytes()); err != nil {
-		return err
+	if styles != nil {
+		styles.body = purgeCSS(styles.body, classes)
+	}
+}
+
+// minifyFiles minifies the HTML, CSS and XML files,
+// and returns the size savings for each file type.
+// Media files are left untouched.
+// .
+func (e *exporter) minifyFiles(files []*exportFile) map[string]*minifyStats {
+	stats := make(map[string]*minifyStats, len(minifiers))
+
+	for _, f := range files {
+		if strings.HasPrefix(f.url, "/media/") {
+			continue
+		}
+
+		ext := filepath.Ext(f.path)
+		minify, ok := minifiers[ext]
+		if !ok {
+			continue
+		}
+
+		if stats[ext] == nil {
+			stats[ext] = &minifyStats{}
+		}
+
+		st := stats[ext]
+		st.files++
+		st.before += len(f.body)
+
+		f.body = minify(f.body)
+		st.after += len(f.body)
+	}
+
+	return stats
+}
+
+// logSummary logs the minification savings, by file type.
+// .
+func (e *exporter) logSummary(stats map[string]*minifyStats) {
+	total := &minifyStats{}
+
+	for _, ext := range sortedKeys(stats) {
+		st := stats[ext]
+		e.logger.Info("files minified", append([]any{"type", strings.TrimPrefix(ext, ".")}, st.attrs()...)...)
+
+		total.files += st.files
+		total.before += st.before
+		total.after += st.after
+	}
+
+	e.logger.Info("files minified", append([]any{"type", "total"}, total.attrs()...)...)
+}
+
+// compressFiles writes a gzip and a brotli sibling for every text file
+// above the size threshold, so that hosts which support precompressed
+// files can serve them directly. Media files are already compressed.
+//
+// ex:
+//
+//	/some-post/index.html  =>  /some-post/index.html.gz, /some-post/index.html.br
+//
+// .
+func (e *exporter) compressFiles(files []*exportFile) error {
+	for _, f := range files {
+		if !isCompressible(f.path) || len(f.body) < e.compressMinSize {
+			continue
+		}
+
+		for _, enc := range encodings {
+			data, err := enc.compress(f.body, enc.bestLevel)
+			if err != nil {
+				return fmt.Errorf("could not compress [%s]: %w", f.url, err)
+			}
+
+			if err := utils.WriteFile(f.path+enc.ext, data); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
 }
 
-// makePath builds the output path based on the given route being rendered.
+// fingerprintAssets renames the stylesheets and media files with a
+// content hash, and updates the references in the HTML files.
+// It returns the manifest of original urls to fingerprinted urls.
+//
+// ex:
+//
+//	/styles.css           =>  /styles.3f9a1c07d2.css
+//	/media/go-time.webp   =>  /media/go-time.8b2e4f6a10.webp
+//
+// .
+func (e *exporter) fingerprintAssets(files []*exportFile) map[string]string {
+	manifest := make(map[string]string, len(files))
+
+	// Rename the assets.
+	for _, f := range files {
+		if !f.isAsset() {
+			continue
+		}
+
+		url := fingerprintUrl(f.url, f.body)
+		manifest[f.url] = url
+
+		f.url = url
+		f.path = e.makePath(url)
+	}
+
+	// The rendered pages link to the stylesheets fingerprinted by the
+	// server, before they are purged and minified.
+	urls := maps.Clone(manifest)
+	for url, served := range e.assetUrls {
+		if fingerprinted, ok := manifest[url]; ok {
+			urls[served] = fingerprinted
+		}
+	}
+
+	// Matches root-relative and absolute urls within attributes,
+	// inline styles and srcsets. The urls include the base path.
+	pattern := regexp.MustCompile(`((?:^|["'(=,\s])` + regexp.QuoteMeta(e.basePath) + `|` + regexp.QuoteMeta(e.siteUrl) + `)(/[^"'\s()?#<>,]+)`)
+
+	// Update the references in the HTML files.
+	for _, f := range files {
+		if !f.isHTML() {
+			continue
+		}
+
+		f.body = pattern.ReplaceAllFunc(f.body, func(match []byte) []byte {
+			m := pattern.FindSubmatch(match)
+			if url, ok := urls[string(m[2])]; ok {
+				return append(append([]byte{}, m[1]...), url...)
+			}
+			return match
+		})
+	}
+
+	return manifest
+}
+
+// makePath builds the file path in the build directory, for the given route.
 //
 // Ex:
 //
@@ -165,18 +517,121 @@ func (e *exporter) exportPage(url, dstPath string) error {
 // .
 func (e *exporter) makePath(url string) string {
 	if url == "/CNAME" {
-		return filepath.Join(e.outputPath, "/CNAME")
+		return filepath.Join(e.buildPath, "/CNAME")
 	}
 
 	if url == "/404/" {
-		return filepath.Join(e.outputPath, "404.html")
+		return filepath.Join(e.buildPath, "404.html")
 	}
 
-	p := filepath.Join(e.outputPath, url)
+	p := filepath.Join(e.buildPath, url)
 
-	if ext := filepath.Ext(p); ext == "" {
+	// The build directory is a dotfile, so only the url has the extension.
+	if ext := path.Ext(url); ext == "" {
 		p = filepath.Join(p, "index.html")
 	}
 
 	return p
 }
+
+// ------------------------------------------------------------------
+//
+//
+// Type: exportFile
+//
+//
+// ------------------------------------------------------------------
+
+// exportFile is a rendered route, waiting to be written to the output path.
+// .
+type exportFile struct {
+	url  string
+	path string
+	body []byte
+}
+
+// isHTML reports if the file is an HTML document.
+// .
+func (f *exportFile) isHTML() bool {
+	return filepath.Ext(f.path) == ".html"
+}
+
+// isMedia reports if the file is copied from the media directory.
+// .
+func (f *exportFile) isMedia() bool {
+	return strings.HasPrefix(f.url, "/media/")
+}
+
+// countMedia returns the number of media files.
+// .
+func countMedia(files []*exportFile) int {
+	n := 0
+	for _, f := range files {
+		if f.isMedia() {
+			n++
+		}
+	}
+	return n
+}
+
+// isAsset reports if the file is a stylesheet or a media file,
+// which can be fingerprinted.
+// .
+func (f *exportFile) isAsset() bool {
+	if f.url == "/styles.css" || f.url == "/highlight.css" {
+		return true
+	}
+	return strings.HasPrefix(f.url, "/media/") && filepath.Ext(f.url) != ""
+}
+
+// ------------------------------------------------------------------
+//
+//
+// Type: minifyStats
+//
+//
+// ------------------------------------------------------------------
+
+// minifyStats stores the size savings of minified files.
+// .
+type minifyStats struct {
+	files  int
+	before int
+	after  int
+}
+
+// attrs returns the stats as log attributes.
+// .
+func (st *minifyStats) attrs() []any {
+	saved := 0.0
+	if st.before > 0 {
+		saved = 100 * float64(st.before-st.after) / float64(st.before)
+	}
+
+	return []any{
+		"files", st.files,
+		"before", utils.FormatBytes(st.before),
+		"after", utils.FormatBytes(st.after),
+		"saved", fmt.Sprintf("%.1f%%", saved),
+	}
+}
+
+// ------------------------------------------------------------------
+//
+//
+// Fingerprint helpers
+//
+//
+// ------------------------------------------------------------------
+
+// The name of the asset manifest, in the export directory.
+const assetManifestName = "assets.json"
+
+// fingerprintUrl inserts the content hash of the body before the url extension.
+//
+// ex: "/styles.css"  =>  "/styles.3f9a1c07d2.css"
+// .
+func fingerprintUrl(url string, body []byte) string {
+	ext := path.Ext(url)
+	return fmt.Sprintf("%s.%s%s", strings.TrimSuffix(url, ext), utils.Hash(body), ext)
+}
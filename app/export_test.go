@@ -0,0 +1,100 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFingerprints(t *testing.T) {
+	g := newTestSite(t, nil)
+	h := g.routes()
+
+	get := func(h http.Handler, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w
+	}
+
+	// The pages link to the stylesheets fingerprinted by the server.
+	home := get(h, "/").Body.String()
+	for _, url := range g.assetUrls {
+		if !strings.Contains(home, `href="`+url+`"`) {
+			t.Errorf("the home page does not link to %s", url)
+		}
+	}
+
+	tests := []struct {
+		url       string
+		status    int
+		immutable bool
+	}{
+		{g.assetUrls["/styles.css"], http.StatusOK, true},
+		{g.assetUrls["/highlight.css"], http.StatusOK, true},
+		{"/styles.0000000000.css", http.StatusNotFound, false},
+		{"/styles.css", http.StatusOK, false},
+	}
+
+	for _, tt := range tests {
+		w := get(h, tt.url)
+		if w.Code != tt.status {
+			t.Errorf("%s: got status %d, want %d", tt.url, w.Code, tt.status)
+		}
+		if got := w.Header().Get("Cache-Control") == immutableCacheControl; got != tt.immutable {
+			t.Errorf("%s: got immutable %v, want %v", tt.url, got, tt.immutable)
+		}
+	}
+
+	// The export links to the purged stylesheets instead.
+	dir := filepath.Join(t.TempDir(), "dist")
+	if err := g.export(context.Background(), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, assetManifestName))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := map[string]string{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, url := range []string{"/styles.css", "/highlight.css"} {
+		if !strings.Contains(string(index), manifest[url]) {
+			t.Errorf("the exported home page does not link to %s", manifest[url])
+		}
+	}
+	if strings.Contains(string(index), g.assetUrls["/styles.css"]) {
+		t.Errorf("the exported home page links to %s", g.assetUrls["/styles.css"])
+	}
+
+	// The exported fingerprinted assets are cached for a year.
+	fingerprinted, err := readAssetManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dist := &distHandler{root: http.Dir(dir), fingerprinted: fingerprinted}
+
+	for url, immutable := range map[string]bool{manifest["/styles.css"]: true, "/": false} {
+		w := get(dist, url)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: got status %d, want 200", url, w.Code)
+		}
+		if got := w.Header().Get("Cache-Control") == immutableCacheControl; got != immutable {
+			t.Errorf("%s: got immutable %v, want %v", url, got, immutable)
+		}
+	}
+}
@@ -2,17 +2,21 @@ package app
 
 import (
 	"bytes"
+	"context"
 	"embed"
+	"errors"
 	"fmt"
 	htmlTmp "html/template"
 	"io"
 	"io/fs"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
-	"os"
 	"path/filepath"
 	"runtime/debug"
 	"strings"
+	"syscall"
+	"text/tabwriter"
 	textTmp "text/template"
 	"time"
 
@@ -42,8 +46,30 @@ type Gingersnap struct {
 	MediaPath  string
 	ExportPath string
 
+	// The deploy directory or git repository (set from the config)
+	DeployPath string
+
+	// The address for the server to listen on, ex: ":4000"
+	ListenAddr string
+
+	// The gingersnap version, for the export's build manifest
+	Version string
+
+	// The logger for the server and the export (default is a text logger on stderr).
+	// The rendered pages are logged at the debug level.
+	Logger *slog.Logger
+
+	// The directory of the translated UI strings, ex: "i18n/es.json"
+	MessagesPath string
+
+	// The directory of the content templates, ex: "archetypes/post.md"
+	ArchetypesPath string
+
+	// The directory of the template overrides, ex: "templates/post.html"
+	TemplatesPath string
+
 	// The main logger
-	logger *log.Logger
+	logger *slog.Logger
 
 	// Internal assets
 	assets embed.FS
@@ -51,17 +77,33 @@ type Gingersnap struct {
 	// Internal templates
 	templates *htmlTmp.Template
 
+	// The stylesheet, with the safelisted utility classes
+	stylesCSS []byte
+
+	// The stylesheet for highlighted code blocks
+	highlightCSS []byte
+
+	// The fingerprinted urls of the stylesheets, by url,
+	// ex: "/styles.css" => "/styles.3f9a1c07d2.css"
+	assetUrls map[string]string
+
 	// Internal HTTP server.
 	httpServer *http.Server
 
 	// The user's config
 	config *config
 
-	// The user's content
+	// The user's content, for the default language
 	store *store
 
+	// The user's content, by language code
+	stores map[string]*store
+
 	// The user's media
 	media http.FileSystem
+
+	// The timings and sizes of the last build
+	stats *buildStats
 }
 
 // NewGingersnap returns a *Gingernap engine.
@@ -73,63 +115,144 @@ func NewGingersnap() *Gingersnap {
 		PostsPath:  "app/assets/posts",
 		MediaPath:  "app/assets/media",
 		ExportPath: "dist",
+		ListenAddr: ":4000",
+
+		MessagesPath:   "app/assets/i18n",
+		ArchetypesPath: "app/assets/archetypes",
 	}
 }
 
-// Configure wipes and reconfigures the gingersnap engine.
+// Configure wipes and reconfigures the gingersnap engine. If the
+// configuration fails, then the engine is left unchanged, so that
+// the dev server keeps serving the previous build. The build stops
+// early if the context is canceled.
 // .
-func (g *Gingersnap) Configure() {
-
-	// [1/3] Wipe the gingersnap engine -------------------
+func (g *Gingersnap) Configure(ctx context.Context) error {
+	return g.configure(ctx)
+}
 
-	if g.httpServer != nil {
-		g.httpServer.Close()
-		g.httpServer = nil
-	}
-	g.logger = nil
-	g.templates = nil
-	g.config = nil
-	g.store = nil
+// configure builds the engine components, and replaces the current ones.
+// .
+func (g *Gingersnap) configure(ctx context.Context) error {
 
-	// [2/3] Configure the engine components --------------
+	// [1/3] Configure the engine components --------------
 
 	// Construct the logger.
-	logger := log.New(os.Stderr, "", log.Ltime)
+	logger := g.newLogger()
+
+	// Collect the build timings, for the stats.
+	stats := &buildStats{}
 
 	// Read the config file.
 	configBytes, err := utils.ReadFile(g.ConfigPath)
 	if err != nil {
-		logger.Fatalf("read config: %s\n", err)
+		return err
 	}
 
 	// Construct the config.
-	config, err := newConfig(configBytes, g.Debug)
+	config, err := newConfig(configBytes, g.Debug, g.ListenAddr)
 	if err != nil {
-		logger.Fatalf("parse config: %s", err)
+		return fmt.Errorf("could not load config [%s]: %w", g.ConfigPath, err)
+	}
+
+	// Use the deploy path, relative to the config file.
+	config.Deploy.setPath(g.DeployPath)
+
+	// Report the config warnings.
+	for _, warning := range config.Warnings {
+		logger.Warn("config warning", "warning", warning)
 	}
 
 	// Gather the markdown post files.
 	filePaths, err := utils.LocalGlob(g.PostsPath, "md")
 	if err != nil {
-		logger.Fatalf("gather posts: %s", err)
+		return err
+	}
+
+	// Read the translated UI strings.
+	for _, lang := range config.Languages {
+		lang.messages, err = loadMessages(assets, lang.Code, g.MessagesPath)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Parse the markdown posts.
-	pr := newProcessor(filePaths)
-	if err := pr.process(); err != nil {
-		logger.Fatalf("process posts: %s", err)
+	start := time.Now()
+
+	pr := newProcessor(filePaths, config.Languages, config.Permalinks, config.Redirects)
+	if err := pr.process(ctx); err != nil {
+		return err
+	}
+
+	stats.Process = since(start)
+	stats.PostTimes = pr.timings
+	stats.sortTimings()
+
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	// Construct the store from the processed posts.
-	store := newStore()
-	store.InitPosts(pr.postsBySlug)
-	store.InitCategories(pr.categoriesBySlug)
-	store.InitSections()
+	// Construct a store for each language from the processed posts.
+	start = time.Now()
+	stores := make(map[string]*store, len(config.Languages))
+
+	for _, lang := range config.Languages {
+		s := newStore()
+		s.InitPosts(pr.postsByLang[lang.Code])
+		s.InitCategories(pr.categoriesByLang[lang.Code])
+		s.InitArchive(lang.Prefix, lang.messages)
+		s.InitRelated(config.Related.Count)
+		s.InitSections(lang.messages)
+
+		if err := s.InitHomepage(lang.Homepage); err != nil {
+			return fmt.Errorf("could not load config [%s]: %w", g.ConfigPath, err)
+		}
 
-	// Construct the templates, using the embedded FS.
-	templates, err := newTemplate(templates)
+		stores[lang.Code] = s
+
+		stats.Posts += len(s.posts)
+		stats.Pages += len(s.pages)
+		stats.Categories += len(s.categories)
+	}
+
+	// The redirects are shared by all languages,
+	// so they are kept in the default store.
+	store := stores[config.defaultLanguage().Code]
+	store.InitRedirects(pr.redirectsByRoute)
+
+	stats.Stores = since(start)
+
+	// Construct the templates, using the embedded FS
+	// and the project's template overrides.
+	start = time.Now()
+
+	templates, err := newTemplate(templates, g.projectTemplates())
 	if err != nil {
-		logger.Fatalf("parse templates: %s", err)
+		return err
+	}
+
+	stats.Templates = since(start)
+
+	// Read the stylesheet, and generate the stylesheet for highlighted code blocks.
+	stylesCSS, err := assets.ReadFile("assets/css/styles.css")
+	if err != nil {
+		return err
+	}
+
+	highlightCSS, err := newHighlightCSS(config.Highlight, config.Theme)
+	if err != nil {
+		return err
+	}
+
+	// [2/3] Wipe the gingersnap engine -------------------
+
+	// Stop the running server, after its requests are done.
+	if g.httpServer != nil {
+		if err := shutdown(g.httpServer); err != nil {
+			logger.Warn("server shutdown", "error", err)
+		}
+		g.httpServer = nil
 	}
 
 	// [3/3] Construct the gingersnap engine --------------
@@ -138,52 +261,136 @@ func (g *Gingersnap) Configure() {
 	g.assets = assets
 	g.media = http.Dir(g.MediaPath)
 	g.templates = templates
+	g.stylesCSS = stylesCSS
+	g.highlightCSS = highlightCSS
+	g.assetUrls = map[string]string{
+		"/styles.css":    fingerprintUrl("/styles.css", stylesCSS),
+		"/highlight.css": fingerprintUrl("/highlight.css", highlightCSS),
+	}
 	g.config = config
 	g.store = store
+	g.stores = stores
+	g.stats = stats
 	g.httpServer = &http.Server{
 		Addr:         g.config.ListenAddr,
 		Handler:      g.routes(),
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelError),
 		IdleTimeout:  time.Minute,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 	}
+
+	return nil
 }
 
-// Export exports the server as a static site.
+// Export exports the server as a static site, into the export path.
+// If the context is canceled, then the export path is left unchanged.
 // .
-func (g *Gingersnap) Export() error {
+func (g *Gingersnap) Export(ctx context.Context) error {
+	return g.export(ctx, g.ExportPath)
+}
 
-	g.logger = log.New(io.Discard, "", 0)
+// export exports the server as a static site, into the directory.
+// .
+func (g *Gingersnap) export(ctx context.Context, dir string) error {
 
-	ex, err := g.newExporter()
+	ex, err := g.newExporter(dir)
 	if err != nil {
 		return err
 	}
 
-	return ex.export()
+	return ex.export(ctx)
 }
 
-// Repository returns the path of the git repository
-// where the static site will be managed.
+// WriteThemes writes a table of the built-in and custom themes.
 // .
-func (g *Gingersnap) Repository() string {
-	return g.config.Repository
+func (g *Gingersnap) WriteThemes(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "NAME\tPRIMARY\tSECONDARY\tLINK\tDARK PRIMARY\tDARK SECONDARY\tDARK LINK\t")
+
+	for _, p := range g.config.themePreviews() {
+		name := p.Name
+		if p.Custom {
+			name = fmt.Sprintf("%s (custom)", p.Name)
+		}
+		if p.Extends != "" {
+			name = fmt.Sprintf("%s (custom, extends %s)", p.Name, p.Extends)
+		}
+
+		fmt.Fprintf(tw, "%s\t", name)
+		for _, sw := range append(p.Light, p.Dark...) {
+			fmt.Fprintf(tw, "%s\t", sw.Color)
+		}
+		fmt.Fprintln(tw)
+	}
+
+	return tw.Flush()
 }
 
-// RunServer runs the gingersnap server.
+// RunServer runs the gingersnap server, until the context is canceled
+// or the server is reconfigured. Then the server stops, after its
+// requests are done.
 // .
-func (g *Gingersnap) RunServer() {
-	g.logger.Printf("Starting server on %s 🤖\n\n", g.config.ListenAddr)
-	g.httpServer.ListenAndServe()
+func (g *Gingersnap) RunServer(ctx context.Context) error {
+	return serve(ctx, g.httpServer, g.logger)
+}
+
+// The time to wait for the open requests, when the server stops.
+const shutdownTimeout = 10 * time.Second
+
+// serve listens on the server's address, and serves until the context
+// is canceled. A busy address is reported, instead of being ignored.
+// .
+func serve(ctx context.Context, srv *http.Server, logger *slog.Logger) error {
+	ln, err := net.Listen("tcp", srv.Addr)
+	if errors.Is(err, syscall.EADDRINUSE) {
+		return fmt.Errorf("could not listen on [%s]: the address is already in use, ex: by another server", srv.Addr)
+	}
+	if err != nil {
+		return fmt.Errorf("could not listen on [%s]: %w", srv.Addr, err)
+	}
+
+	logger.Info("server started", "addr", srv.Addr)
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errs:
+		// The server was stopped by a reconfigure.
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+
+	case <-ctx.Done():
+		logger.Info("server stopping", "timeout", shutdownTimeout)
+
+		if err := shutdown(srv); err != nil {
+			return err
+		}
+
+		logger.Info("server stopped")
+		return nil
+	}
 }
 
-// Unpack copies the relevant resources into the current directory.
-// This is used when initializing a new gingersnap project.
+// shutdown stops the server, and waits for the open requests to be done.
+// The requests which are still open after the timeout are closed.
 // .
-func (g *Gingersnap) Unpack() {
-	utils.CopyDir(assets, "assets/media", ".")
-	utils.CopyDir(assets, "assets/posts", ".")
-	utils.CopyFile(assets, "assets/config/gingersnap.json", "gingersnap.json")
+func shutdown(srv *http.Server) error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		srv.Close()
+		return fmt.Errorf("could not finish the open requests in %s: %w", shutdownTimeout, err)
+	}
+
+	return nil
 }
 
 // ------------------------------------------------------------------
@@ -197,33 +404,89 @@ func (g *Gingersnap) Unpack() {
 // Routes constructs and returns the complete http.Handler for the server.
 // .
 func (g *Gingersnap) routes() http.Handler {
+	return g.recoverPanic(g.logRequest(g.secureHeaders(mountBasePath(g.router(), g.config.BasePath))))
+}
+
+// router constructs the http.Handler for the site's pages and assets,
+// without the request logging. The exporter uses it directly, and
+// logs the rendered pages itself.
+// .
+func (g *Gingersnap) router() http.Handler {
 	r := http.NewServeMux()
 
-	r.Handle("/", g.handleIndex())
-	r.Handle("/styles.css", g.serveFile(g.assets, "assets/css/styles.css"))
-	r.Handle("/sitemap/", g.handleSitemapHtml())
-	r.Handle("/sitemap.xml", g.handleSitemapXml())
+	r.Handle("/styles.css", g.cacheControl(g.serveBytes("styles.css", g.stylesCSS)))
+	r.Handle("/highlight.css", g.cacheControl(g.serveBytes("highlight.css", g.highlightCSS)))
+	r.Handle(g.assetUrls["/styles.css"], g.immutable(g.serveBytes("styles.css", g.stylesCSS)))
+	r.Handle(g.assetUrls["/highlight.css"], g.immutable(g.serveBytes("highlight.css", g.highlightCSS)))
 	r.Handle("/robots.txt", g.handleRobotsTxt())
 	r.Handle("/CNAME", g.handleCname())
 	r.Handle("/404/", g.handle404())
 	r.Handle("/media/", g.cacheControl(http.StripPrefix("/media", http.FileServer(g.media))))
 
-	// Build routes for all blog posts.
-	for _, p := range g.store.posts {
-		r.Handle(p.Route(), g.handlePost(p))
+	// Build the xml sitemaps. Multilingual sites have a
+	// sitemap for each language, and a sitemap index.
+	if g.config.isMultilingual() {
+		r.Handle("/sitemap.xml", g.handleSitemapIndex())
+
+		for _, lang := range g.config.Languages {
+			r.Handle(lang.sitemapRoute(), g.handleSitemapXml(lang))
+		}
+	} else {
+		r.Handle("/sitemap.xml", g.handleSitemapXml(g.config.defaultLanguage()))
 	}
 
-	// Build routes for all standalone posts (pages).
-	for _, p := range g.store.pages {
-		r.Handle(p.Route(), g.handlePost(p))
+	for _, lang := range g.config.Languages {
+		s := g.stores[lang.Code]
+
+		// Build the homepage and sitemap routes.
+		r.Handle(lang.homeRoute(), g.handleIndex(lang))
+		r.Handle(lang.Prefix+"/sitemap/", g.handleSitemapHtml(lang))
+
+		// Build routes for all blog posts.
+		for _, p := range s.posts {
+			r.Handle(p.Route(), g.handlePost(p))
+		}
+
+		// Build routes for all standalone posts (pages).
+		for _, p := range s.pages {
+			r.Handle(p.Route(), g.handlePost(p))
+		}
+
+		// Build category routes
+		for _, cat := range s.categories {
+			r.Handle(cat.Route(), g.handleCategory(lang, cat))
+		}
+
+		// Build archive routes, by year and month.
+		r.Handle(archiveRoute(lang.Prefix), g.handleArchive(lang))
+
+		for _, y := range s.archive {
+			r.Handle(y.Route(), g.handleArchiveYear(lang, y))
+
+			for _, m := range y.Months {
+				r.Handle(m.Route(), g.handleArchiveMonth(lang, m))
+			}
+		}
+	}
+
+	// Build redirect routes for the aliases and config redirects.
+	for _, rd := range g.store.redirects {
+		r.Handle(rd.Route(), g.handleRedirect(rd))
 	}
 
-	// Build category routes
-	for _, cat := range g.store.categories {
-		r.Handle(cat.Route(), g.handleCategory(cat))
+	// Build the theme preview route, in debug mode only.
+	if g.config.Debug {
+		r.Handle("/themes/", g.handleThemes())
 	}
 
-	return g.recoverPanic(g.logRequest(g.secureHeaders(r)))
+	var h http.Handler = r
+
+	// Compress the responses, to mirror the exported siblings.
+	if g.config.Export.Compress {
+		h = g.compress(h)
+	}
+
+	return h
 }
 
 // ------------------------------------------------------------------
@@ -234,44 +497,40 @@ func (g *Gingersnap) routes() http.Handler {
 //
 // ------------------------------------------------------------------
 
-func (g *Gingersnap) handleIndex() http.HandlerFunc {
-	sections := make([]section, 0, len(g.config.Homepage))
-
-	// Create sections for rendering the homepage.
-	for _, slug := range g.config.Homepage {
-
-		section, ok := g.store.sections[slug]
-		if !ok {
-			panic(fmt.Sprintf("cannot find Section '%s'", slug))
-		}
-		sections = append(sections, section)
-	}
+func (g *Gingersnap) handleIndex(lang *language) http.HandlerFunc {
+	sections := g.stores[lang.Code].homepage
+	alternates := g.languageAlternates((*language).homeRoute)
 
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		// Handle 404
-		if r.URL.Path != "/" {
+		if r.URL.Path != lang.homeRoute() {
 			g.errNotFound(w)
 			return
 		}
 
-		rd := g.newRenderData(r)
+		rd := g.newRenderData(r, lang)
 		rd.Sections = sections
+		rd.Alternates = alternates
 
 		g.render(w, http.StatusOK, "index", &rd)
 	}
 }
 
 func (g *Gingersnap) handlePost(post *post) http.HandlerFunc {
+	s := g.stores[post.Lang.Code]
+	alternates := g.postAlternates(post)
+
 	return func(w http.ResponseWriter, r *http.Request) {
 
-		rd := g.newRenderData(r)
+		rd := g.newRenderData(r, post.Lang)
 		rd.Title = post.Title
 		rd.Description = post.Description
 		rd.Heading = post.Heading
 		rd.Post = post
-		rd.LatestPosts = g.store.postsLatestSm
-		rd.RelatedPosts = g.store.RelatedPosts(post)
+		rd.LatestPosts = s.postsLatestSm
+		rd.RelatedPosts = s.RelatedPosts(post)
+		rd.Alternates = alternates
 
 		if post.Image.IsEmpty() {
 			rd.Image = g.config.Site.Image
@@ -283,20 +542,20 @@ func (g *Gingersnap) handlePost(post *post) http.HandlerFunc {
 	}
 }
 
-func (g *Gingersnap) handleCategory(cat category) http.HandlerFunc {
+func (g *Gingersnap) handleCategory(lang *language, cat category) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		// Get the Posts by Category
-		posts, ok := g.store.postsByCategory[cat]
+		posts, ok := g.stores[lang.Code].postsByCategory[cat]
 		if !ok {
-			g.logger.Printf("Cannot find Posts for Category '%s'", cat.Slug)
+			g.logger.Warn("cannot find posts for category", "category", cat.Slug)
 			g.errNotFound(w)
 			return
 		}
 
-		rd := g.newRenderData(r)
-		rd.Title = fmt.Sprintf("%s Posts - Explore our Content on %s", cat.Title, g.config.Site.Name)
-		rd.Description = fmt.Sprintf("Browse through the %s category on %s and take a look at our posts.", cat.Title, g.config.Site.Name)
+		rd := g.newRenderData(r, lang)
+		rd.Title = rd.T("category_title", cat.Title, g.config.Site.Name)
+		rd.Description = rd.T("category_description", cat.Title, g.config.Site.Name)
 		rd.Heading = cat.Title
 		rd.Category = cat
 		rd.Posts = posts
@@ -305,6 +564,89 @@ func (g *Gingersnap) handleCategory(cat category) http.HandlerFunc {
 	}
 }
 
+func (g *Gingersnap) handleArchive(lang *language) http.HandlerFunc {
+	alternates := g.languageAlternates(func(l *language) string { return archiveRoute(l.Prefix) })
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		// Handle 404
+		if r.URL.Path != archiveRoute(lang.Prefix) {
+			g.errNotFound(w)
+			return
+		}
+
+		rd := g.newRenderData(r, lang)
+		rd.Title = rd.T("archive_title", g.config.Site.Name)
+		rd.Description = rd.T("archive_description", g.config.Site.Name)
+		rd.Heading = rd.T("archive")
+		rd.Archive = g.stores[lang.Code].archive
+		rd.Alternates = alternates
+
+		g.render(w, http.StatusOK, "archive", &rd)
+	}
+}
+
+func (g *Gingersnap) handleArchiveYear(lang *language, y archiveYear) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		rd := g.newRenderData(r, lang)
+		rd.Heading = rd.T("archive_year_heading", y.Year)
+		rd.Title = rd.T("archive_period_title", rd.Heading, g.config.Site.Name)
+		rd.Description = rd.T("archive_period_description", g.config.Site.Name, fmt.Sprint(y.Year))
+		rd.Archive = []archiveYear{y}
+		rd.Posts = y.Posts
+
+		g.render(w, http.StatusOK, "archive", &rd)
+	}
+}
+
+func (g *Gingersnap) handleArchiveMonth(lang *language, m archiveMonth) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		rd := g.newRenderData(r, lang)
+		rd.Heading = rd.T("archive_month_heading", m.Name, m.Year)
+		rd.Title = rd.T("archive_period_title", rd.Heading, g.config.Site.Name)
+		rd.Description = rd.T("archive_period_description", g.config.Site.Name, fmt.Sprintf("%s %d", m.Name, m.Year))
+		rd.Posts = m.Posts
+
+		g.render(w, http.StatusOK, "archive", &rd)
+	}
+}
+
+func (g *Gingersnap) handleThemes() http.HandlerFunc {
+	previews := g.config.themePreviews()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		rd := g.newRenderData(r, g.config.defaultLanguage())
+		rd.Title = fmt.Sprintf("Themes - %s", g.config.Site.Name)
+		rd.Heading = "Themes"
+		rd.Themes = previews
+
+		g.render(w, http.StatusOK, "themes", &rd)
+	}
+}
+
+// handleRedirect responds with a permanent redirect. The body is a page
+// which redirects with a meta refresh, so that the exported page works
+// on static hosts which do not support redirects.
+// .
+func (g *Gingersnap) handleRedirect(rd redirect) http.HandlerFunc {
+	to := rd.To
+	if strings.HasPrefix(to, "/") {
+		to = g.config.Site.Url + to
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		rd := g.newRenderData(r, g.config.defaultLanguage())
+		rd.Title = rd.T("redirect_title", g.config.Site.Name)
+		rd.RedirectUrl = to
+
+		w.Header().Set("Location", to)
+		g.render(w, http.StatusMovedPermanently, "redirect", &rd)
+	}
+}
+
 func (g *Gingersnap) handleCname() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
@@ -331,7 +673,7 @@ Sitemap: {{.}}/sitemap.xml
 	// Prepare the robots template.
 	tmpl, err := textTmp.New("").Parse(strings.TrimPrefix(robotsTemplate, "\n"))
 	if err != nil {
-		g.logger.Fatal(err)
+		panic(err)
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -350,15 +692,21 @@ Sitemap: {{.}}/sitemap.xml
 	}
 }
 
-func (g *Gingersnap) handleSitemapXml() http.HandlerFunc {
+// handleSitemapXml serves the xml sitemap of a language. For
+// multilingual sites, the translated pages have hreflang links.
+// .
+func (g *Gingersnap) handleSitemapXml(lang *language) http.HandlerFunc {
 
 	sitemapTemplate := `
 <?xml version="1.0" encoding="UTF-8"?>
-<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
-	{{- range $key, $value := .}}
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"{{if .Multilingual}} xmlns:xhtml="http://www.w3.org/1999/xhtml"{{end}}>
+	{{- range $key, $value := .Urls}}
 	<url>
 		<loc>{{$key}}</loc>
-		{{if $value}}<lastmod>{{$value}}</lastmod>{{end}}
+		{{if $value.LastMod}}<lastmod>{{$value.LastMod}}</lastmod>{{end}}
+		{{- range $value.Alternates}}
+		<xhtml:link rel="alternate" hreflang="{{.Lang}}" href="{{.Url}}"/>
+		{{- end}}
 	</url>
 	{{- end}}
 </urlset>
@@ -370,9 +718,11 @@ func (g *Gingersnap) handleSitemapXml() http.HandlerFunc {
 		panic(err)
 	}
 
-	// The urlSet is a map of urls to lastmod dates.
+	s := g.stores[lang.Code]
+
+	// The urlSet is a map of urls to lastmod dates and hreflang links.
 	// It is used to render the sitemap.
-	urlSet := make(map[string]string, len(g.store.posts)*2)
+	urlSet := make(map[string]sitemapUrl, len(s.posts)*2)
 
 	// permalink is a helper function which generates
 	// the permalink for a given path
@@ -381,27 +731,52 @@ func (g *Gingersnap) handleSitemapXml() http.HandlerFunc {
 	}
 
 	// Add sitemap entries for the index page.
-	urlSet[permalink("/")] = ""
+	urlSet[permalink(lang.homeRoute())] = sitemapUrl{
+		Alternates: g.languageAlternates((*language).homeRoute),
+	}
 
 	// Add sitemap entries for all the blog posts.
-	for _, post := range g.store.posts {
+	for _, post := range s.posts {
 		lastMod := ""
 
 		if ts := post.LatestTS(); ts > 0 {
 			lastMod = time.Unix(int64(ts), 0).UTC().Format("2006-01-02T00:00:00+00:00")
 		}
 
-		urlSet[permalink(post.Route())] = lastMod
+		urlSet[permalink(post.Route())] = sitemapUrl{
+			LastMod:    lastMod,
+			Alternates: g.postAlternates(post),
+		}
 	}
 
 	// Add sitemap entries for all the standalone posts (pages).
-	for _, post := range g.store.pages {
-		urlSet[permalink(post.Route())] = ""
+	for _, post := range s.pages {
+		urlSet[permalink(post.Route())] = sitemapUrl{
+			Alternates: g.postAlternates(post),
+		}
 	}
 
 	// Add sitemap entries for all the categories.
-	for _, cat := range g.store.categories {
-		urlSet[permalink(cat.Route())] = ""
+	for _, cat := range s.categories {
+		urlSet[permalink(cat.Route())] = sitemapUrl{}
+	}
+
+	// Add sitemap entries for the archive pages.
+	urlSet[permalink(archiveRoute(lang.Prefix))] = sitemapUrl{
+		Alternates: g.languageAlternates(func(l *language) string { return archiveRoute(l.Prefix) }),
+	}
+
+	for _, y := range s.archive {
+		urlSet[permalink(y.Route())] = sitemapUrl{}
+
+		for _, m := range y.Months {
+			urlSet[permalink(m.Route())] = sitemapUrl{}
+		}
+	}
+
+	data := map[string]any{
+		"Multilingual": g.config.isMultilingual(),
+		"Urls":         urlSet,
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -409,7 +784,7 @@ func (g *Gingersnap) handleSitemapXml() http.HandlerFunc {
 
 		// Write the template to the buffer first.
 		// If error, then respond with a server error and return.
-		if err := tmpl.Execute(buf, urlSet); err != nil {
+		if err := tmpl.Execute(buf, data); err != nil {
 			g.internalServerError(w, err)
 			return
 		}
@@ -420,15 +795,67 @@ func (g *Gingersnap) handleSitemapXml() http.HandlerFunc {
 	}
 }
 
-func (g *Gingersnap) handleSitemapHtml() http.HandlerFunc {
+// sitemapUrl is an entry of the xml sitemap.
+// .
+type sitemapUrl struct {
+	LastMod    string
+	Alternates []alternate
+}
+
+// handleSitemapIndex serves the sitemap index of a multilingual
+// site, which links to the xml sitemap of every language.
+// .
+func (g *Gingersnap) handleSitemapIndex() http.HandlerFunc {
+
+	sitemapIndexTemplate := `
+<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	{{- range .}}
+	<sitemap>
+		<loc>{{.}}</loc>
+	</sitemap>
+	{{- end}}
+</sitemapindex>
+`
+
+	// Prepare the sitemap index template.
+	tmpl, err := textTmp.New("").Parse(strings.TrimPrefix(sitemapIndexTemplate, "\n"))
+	if err != nil {
+		panic(err)
+	}
+
+	sitemaps := make([]string, 0, len(g.config.Languages))
+	for _, lang := range g.config.Languages {
+		sitemaps = append(sitemaps, g.config.Site.Url+lang.sitemapRoute())
+	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
+		buf := new(bytes.Buffer)
 
-		rd := g.newRenderData(r)
-		rd.Title = fmt.Sprintf("Sitemap - Browse through all Posts on %s", g.config.Site.Name)
-		rd.Description = fmt.Sprintf("Browse through the sitemap on %s and take a look at our posts.", g.config.Site.Name)
-		rd.Heading = "Posts"
-		rd.Posts = g.store.posts
+		// Write the template to the buffer first.
+		// If error, then respond with a server error and return.
+		if err := tmpl.Execute(buf, sitemaps); err != nil {
+			g.internalServerError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf.Bytes())
+	}
+}
+
+func (g *Gingersnap) handleSitemapHtml(lang *language) http.HandlerFunc {
+	alternates := g.languageAlternates(func(l *language) string { return l.Prefix + "/sitemap/" })
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		rd := g.newRenderData(r, lang)
+		rd.Title = rd.T("sitemap_title", g.config.Site.Name)
+		rd.Description = rd.T("sitemap_description", g.config.Site.Name)
+		rd.Heading = rd.T("sitemap_heading")
+		rd.Posts = g.stores[lang.Code].posts
+		rd.Alternates = alternates
 
 		g.render(w, http.StatusOK, "sitemap", &rd)
 	}
@@ -442,27 +869,26 @@ func (g *Gingersnap) handleSitemapHtml() http.HandlerFunc {
 //
 // ------------------------------------------------------------------
 
-// ServeFile returns a http.Handler that serves a specific file.
+// contentTypes maps file extensions to the content types
+// supported by the reusable handlers.
+var contentTypes = map[string]string{
+	".css": "text/css; charset=utf-8",
+	".txt": "text/plain; charset=utf-8",
+	".xml": "application/xml; charset=utf-8",
+}
+
+// serveBytes returns a http.Handler that serves generated content.
+// The content type is determined by the extension of the file name.
 // .
-func (g *Gingersnap) serveFile(efs embed.FS, fileName string) http.Handler {
+func (g *Gingersnap) serveBytes(fileName string, data []byte) http.Handler {
 	ext := filepath.Ext(fileName)
 
-	contentTypes := map[string]string{
-		".css": "text/css; charset=utf-8",
-		".txt": "text/plain; charset=utf-8",
-		".xml": "application/xml; charset=utf-8",
-	}
-
 	// Check that the content type exists for the given extension.
 	if _, ok := contentTypes[ext]; !ok {
-		g.logger.Fatalf("content type for [%s] not supported", ext)
+		panic(fmt.Errorf("content type for [%s] not supported", ext))
 	}
 
 	fn := func(w http.ResponseWriter, r *http.Request) {
-		data, err := efs.ReadFile(fileName)
-		if err != nil {
-			g.errInternalServer(w, err)
-		}
 		w.Header().Set("Content-Type", contentTypes[ext])
 		w.WriteHeader(http.StatusOK)
 		w.Write(data)
@@ -489,9 +915,9 @@ func (g *Gingersnap) errNotFound(w http.ResponseWriter) {
 // But when exporting the site, we need to render the not-found template with a 200 instead.
 // .
 func (g *Gingersnap) render404(w http.ResponseWriter, status int) {
-	rd := g.newRenderData(nil)
+	rd := g.newRenderData(nil, g.config.defaultLanguage())
 	rd.AppError = "404"
-	rd.Title = fmt.Sprintf("Page Not Found - %s", g.config.Site.Name)
+	rd.Title = rd.T("not_found_title", g.config.Site.Name)
 	rd.LatestPosts = g.store.postsLatest
 
 	g.render(w, status, "error", &rd)
@@ -501,13 +927,14 @@ func (g *Gingersnap) render404(w http.ResponseWriter, status int) {
 // If debug is enabled, then the stack trace is also shown.
 // .
 func (g *Gingersnap) errInternalServer(w http.ResponseWriter, err error) {
-	trace := fmt.Sprintf("%s\n%s", err.Error(), debug.Stack())
-	g.logger.Output(2, trace)
+	stack := debug.Stack()
+	trace := fmt.Sprintf("%s\n%s", err.Error(), stack)
+	g.logger.Error("internal server error", "error", err, "stack", string(stack))
 	status := http.StatusInternalServerError
 
-	rd := g.newRenderData(nil)
+	rd := g.newRenderData(nil, g.config.defaultLanguage())
 	rd.AppError = "500"
-	rd.Title = fmt.Sprintf("Internal Server Error - %s", g.config.Site.Name)
+	rd.Title = rd.T("server_error_title", g.config.Site.Name)
 	rd.LatestPosts = g.store.postsLatest
 
 	if g.config.Debug {
@@ -521,8 +948,9 @@ func (g *Gingersnap) errInternalServer(w http.ResponseWriter, err error) {
 // This is used in the case where the `Render()` method fails.
 // .
 func (g *Gingersnap) internalServerError(w http.ResponseWriter, err error) {
-	trace := fmt.Sprintf("%s\n%s", err.Error(), debug.Stack())
-	g.logger.Output(2, trace)
+	stack := debug.Stack()
+	trace := fmt.Sprintf("%s\n%s", err.Error(), stack)
+	g.logger.Error("internal server error", "error", err, "stack", string(stack))
 	status := http.StatusInternalServerError
 
 	if g.config.Debug {
@@ -547,6 +975,12 @@ func (g *Gingersnap) render(w http.ResponseWriter, status int, page string, data
 
 	w.WriteHeader(status)
 
+	// Serve the links under the base path, if any.
+	if g.config.BasePath != "" {
+		w.Write(prefixLinks(buf.Bytes(), g.config.BasePath))
+		return
+	}
+
 	// Write the contents of the buffer to the http.ResponseWriter.
 	buf.WriteTo(w)
 }
@@ -559,11 +993,12 @@ func (g *Gingersnap) render(w http.ResponseWriter, status int, page string, data
 //
 // ------------------------------------------------------------------
 
-// logResponseWriter allows us to capture the response status code.
+// logResponseWriter allows us to capture the response status code and size.
 // .
 type logResponseWriter struct {
 	http.ResponseWriter
 	status int
+	size   int
 }
 
 func (w *logResponseWriter) WriteHeader(status int) {
@@ -571,11 +1006,25 @@ func (w *logResponseWriter) WriteHeader(status int) {
 	w.ResponseWriter.WriteHeader(status)
 }
 
-// Logger is a middleware which logs the http request and response status.
+func (w *logResponseWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+// logRequest is a middleware which logs the http request, with its
+// id, response status, size and duration. The server errors are
+// logged at the error level, and the client errors at the warn level.
 // .
 func (g *Gingersnap) logRequest(next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
 
+		id := r.Header.Get(requestIdHeader)
+		if id == "" {
+			id = newRequestId()
+		}
+		w.Header().Set(requestIdHeader, id)
+
 		ww := &logResponseWriter{
 			ResponseWriter: w,
 			status:         http.StatusOK,
@@ -584,13 +1033,21 @@ func (g *Gingersnap) logRequest(next http.Handler) http.Handler {
 		// Defer the logging call.
 		defer func(start time.Time) {
 
-			g.logger.Printf(
-				"%s %d %s %s %s",
-				"[request]",
-				ww.status,
-				r.Method,
-				r.URL.RequestURI(),
-				time.Since(start),
+			level := slog.LevelInfo
+			switch {
+			case ww.status >= 500:
+				level = slog.LevelError
+			case ww.status >= 400:
+				level = slog.LevelWarn
+			}
+
+			g.logger.LogAttrs(r.Context(), level, "request",
+				slog.String("id", id),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.RequestURI()),
+				slog.Int("status", ww.status),
+				slog.Int("size", ww.size),
+				slog.Duration("duration", time.Since(start)),
 			)
 
 		}(time.Now())
@@ -654,6 +1111,25 @@ func (g *Gingersnap) cacheControl(next http.Handler) http.HandlerFunc {
 	}
 }
 
+// The caching policy for fingerprinted assets.
+const immutableCacheControl = "public, max-age=31536000, immutable"
+
+// immutable is a middleware which sets the caching policy for
+// fingerprinted assets, like `/styles.3f9a1c07d2.css`. Their urls
+// change with their content, so they are cached for a year,
+// except in debug mode.
+// .
+func (g *Gingersnap) immutable(next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if g.config.Debug {
+			w.Header().Set("Cache-Control", "no-cache")
+		} else {
+			w.Header().Set("Cache-Control", immutableCacheControl)
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
 // ------------------------------------------------------------------
 //
 //
@@ -662,15 +1138,26 @@ func (g *Gingersnap) cacheControl(next http.Handler) http.HandlerFunc {
 //
 // ------------------------------------------------------------------
 
-// NewTemplate parses and loads all templates from
-// the the given filesystem interface.
+// NewTemplate parses and loads all templates from the given FS.
+// The templates in the overrides FS (if any) replace the
+// built-in templates with the same names.
 // .
-func newTemplate(files fs.FS) (*htmlTmp.Template, error) {
+func newTemplate(files, overrides fs.FS) (*htmlTmp.Template, error) {
 	funcs := htmlTmp.FuncMap{
 		"safe": func(content string) htmlTmp.HTML {
 			return htmlTmp.HTML(content)
 		},
 	}
 
-	return htmlTmp.New("").Funcs(funcs).ParseFS(files, "assets/templates/*.html")
+	tmpl, err := htmlTmp.New("").Funcs(funcs).ParseFS(files, "assets/templates/*.html")
+	if err != nil || overrides == nil {
+		return tmpl, err
+	}
+
+	// Skip the overrides if the directory has no templates.
+	if names, err := fs.Glob(overrides, "*.html"); err != nil || len(names) == 0 {
+		return tmpl, err
+	}
+
+	return tmpl.ParseFS(overrides, "*.html")
 }
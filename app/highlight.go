@@ -0,0 +1,227 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/yuin/goldmark"
+	high "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/util"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Type: highlight
+//
+//
+// ------------------------------------------------------------------
+
+// highlight stores the syntax highlighting settings.
+// .
+type highlight struct {
+	// The chroma style for the light color scheme
+	Style string `json:"style"`
+
+	// The chroma style for the dark color scheme
+	DarkStyle string `json:"darkStyle"`
+}
+
+// The default syntax highlighting styles.
+const defaultHighlightStyle = "tango"
+const defaultHighlightDarkStyle = "monokai"
+
+// validate sets the default styles and checks
+// that the requested styles exist.
+// .
+func (h *highlight) validate() error {
+	if h.Style == "" {
+		h.Style = defaultHighlightStyle
+	}
+
+	if h.DarkStyle == "" {
+		h.DarkStyle = defaultHighlightDarkStyle
+	}
+
+	for _, name := range []string{h.Style, h.DarkStyle} {
+		if _, ok := styles.Registry[name]; !ok {
+			return fmt.Errorf("could not load highlight style [%s]", name)
+		}
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------
+//
+//
+// Syntax highlighting
+//
+//
+// ------------------------------------------------------------------
+
+// newHighlighting returns the goldmark extension for highlighting
+// fenced code blocks. Tokens are rendered with CSS classes instead
+// of inline styles, so that the colors can be swapped with a stylesheet.
+//
+// Fenced code blocks accept the following attributes:
+//
+//	```go {linenos=true, hl_lines=[2, "4-6"], filename="main.go"}
+//
+// .
+func newHighlighting() goldmark.Extender {
+	return high.NewHighlighting(
+		high.WithFormatOptions(
+			chromahtml.WithClasses(true),
+			chromahtml.TabWidth(4),
+		),
+		high.WithWrapperRenderer(codeBlockWrapper),
+	)
+}
+
+// codeBlockWrapper wraps every code block in a <figure> element,
+// and adds a caption if the `filename` attribute is given.
+//
+// Unhighlighted code blocks (no language, or an unknown language)
+// are not formatted by chroma, so the <pre> and <code> tags
+// are written here instead.
+// .
+func codeBlockWrapper(w util.BufWriter, ctx high.CodeBlockContext, entering bool) {
+	if !entering {
+		if !ctx.Highlighted() {
+			w.WriteString("</code></pre>")
+		}
+		w.WriteString("</figure>\n")
+		return
+	}
+
+	w.WriteString(`<figure class="code-block">`)
+
+	if attrs := ctx.Attributes(); attrs != nil {
+		if v, ok := attrs.GetString("filename"); ok {
+			if filename, ok := v.([]byte); ok {
+				w.WriteString(`<figcaption class="code-filename">`)
+				w.Write(util.EscapeHTML(filename))
+				w.WriteString(`</figcaption>`)
+			}
+		}
+	}
+
+	if !ctx.Highlighted() {
+		w.WriteString("<pre><code")
+		if lang, ok := ctx.Language(); ok {
+			w.WriteString(` class="language-`)
+			w.Write(util.EscapeHTML(lang))
+			w.WriteString(`"`)
+		}
+		w.WriteString(">")
+	}
+}
+
+// newHighlightCSS generates the stylesheet for the highlighted code blocks.
+// The light style is applied by default, and the dark style is applied
+// according to the theme's dark mode and toggle settings.
+// .
+func newHighlightCSS(h highlight, t theme) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	light, err := styleCSS(h.Style)
+	if err != nil {
+		return nil, err
+	}
+
+	dark, err := styleCSS(h.DarkStyle)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write the light styles.
+	buf.Write(light)
+
+	// The dark styles are always applied, unless
+	// the reader toggles the light color scheme.
+	if t.DarkMode.IsDark() {
+		buf.Write(dark)
+		if t.Toggle {
+			buf.Write(scopeCSS(light, `:root[data-theme="light"]`))
+		}
+		return buf.Bytes(), nil
+	}
+
+	// The dark styles follow the user's system preference,
+	// unless the reader toggles the light color scheme.
+	if t.DarkMode.IsAuto() {
+		buf.WriteString("@media (prefers-color-scheme: dark) {\n")
+		buf.Write(scopeCSS(dark, `:root:not([data-theme="light"])`))
+		buf.WriteString("}\n")
+	}
+
+	// The dark styles are applied when the reader
+	// toggles the dark color scheme.
+	if t.Toggle {
+		buf.Write(scopeCSS(dark, `:root[data-theme="dark"]`))
+	}
+
+	return buf.Bytes(), nil
+}
+
+// styleCSS returns the CSS classes for the given chroma style.
+// .
+func styleCSS(name string) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	style := styles.Get(name)
+	formatter := chromahtml.New(chromahtml.WithClasses(true))
+
+	if err := formatter.WriteCSS(buf, style); err != nil {
+		return nil, fmt.Errorf("highlight css [%s]: %w", name, err)
+	}
+
+	// The article styles set a background and text color on every <pre> tag.
+	// Restate the style's colors with a more specific selector, so that
+	// the code block colors are not overridden.
+	bg := chromahtml.StyleEntryToCSS(style.Get(chroma.Background))
+	fmt.Fprintf(buf, ".a26 .chroma { %s }\n", bg)
+
+	return buf.Bytes(), nil
+}
+
+// scopeCSS prefixes every selector of the given CSS rules with a scope.
+// The rules are expected to be written one per line, as chroma does.
+//
+// ex:
+//
+//	/* Keyword */ .chroma .k { color: #204a87 }
+//
+//	// becomes
+//	:root[data-theme="dark"] .chroma .k { color: #204a87 }
+//
+// .
+func scopeCSS(css []byte, scope string) []byte {
+	buf := new(bytes.Buffer)
+
+	for _, line := range strings.Split(string(css), "\n") {
+		// Strip the leading comment.
+		if _, after, ok := strings.Cut(line, "*/"); ok {
+			line = after
+		}
+
+		selectors, body, ok := strings.Cut(line, "{")
+		if !ok {
+			continue
+		}
+
+		// Prefix each selector in the group.
+		parts := strings.Split(selectors, ",")
+		for i := range parts {
+			parts[i] = fmt.Sprintf("%s %s", scope, strings.TrimSpace(parts[i]))
+		}
+
+		fmt.Fprintf(buf, "%s {%s\n", strings.Join(parts, ", "), body)
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gingersnap/app/utils"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Type: homepageSection
+//
+//
+// ------------------------------------------------------------------
+
+// homepageSection stores the settings of a homepage section.
+//
+// It can be given as a section name, or as an object
+// with the title, limit, sorting, display and filters.
+//
+// ex:
+//
+//	"$latest"
+//
+//	{"section": "go", "title": "Go Tutorials", "limit": 3, "sort": "updated", "display": "list"}
+//
+// .
+type homepageSection struct {
+	// The category slug, or a custom section like "$latest"
+	Section string `json:"section"`
+
+	// The section heading (default is the section title)
+	Title string `json:"title"`
+
+	// The maximum number of posts (default is the section limit)
+	Limit int `json:"limit"`
+
+	// The post order: "pubdate", "updated" or "title" (default "pubdate")
+	Sort string `json:"sort"`
+
+	// The post layout: "grid" or "list" (default is the site display)
+	Display display `json:"display"`
+
+	// If only the featured posts are shown
+	Featured bool `json:"featured"`
+
+	// If set, only the posts with the tag are shown
+	Tag string `json:"tag"`
+}
+
+// The post orders for the homepage sections.
+const sortPubdate = "pubdate"
+const sortUpdated = "updated"
+const sortTitle = "title"
+
+// UnmarshalJSON parses the section from a string or an object.
+// .
+func (h *homepageSection) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		h.Section = name
+		return nil
+	}
+
+	// Use an alias type to avoid recursing into this method.
+	type homepageSectionAlias homepageSection
+
+	return json.Unmarshal(data, (*homepageSectionAlias)(h))
+}
+
+// validate sets the default settings, and checks the sort and display.
+// The section name is checked once the posts are known, see `InitHomepage`.
+// .
+func (h *homepageSection) validate(siteDisplay display) error {
+	if h.Section == "" {
+		return fmt.Errorf("could not load homepage section: missing section")
+	}
+
+	if h.Limit < 0 {
+		return fmt.Errorf("could not load homepage section [%s]: negative limit", h.Section)
+	}
+
+	switch h.Sort {
+	case "":
+		h.Sort = sortPubdate
+	case sortPubdate, sortUpdated, sortTitle:
+	default:
+		return fmt.Errorf("could not load homepage section [%s]: sort [%s]", h.Section, h.Sort)
+	}
+
+	if h.Display == "" {
+		h.Display = siteDisplay
+	}
+
+	if d := h.Display; !d.IsGrid() && !d.IsList() {
+		return fmt.Errorf("could not load homepage section [%s]: display [%s]", h.Section, d)
+	}
+
+	h.Tag = utils.Slugify(h.Tag)
+
+	return nil
+}
+
+// posts filters, sorts and limits the section posts.
+// The given posts are sorted by pubdate, and are not modified.
+// .
+func (h *homepageSection) posts(posts []*post, featured bool, limit int) []*post {
+	if h.Limit > 0 {
+		limit = h.Limit
+	}
+
+	selected := make([]*post, 0, len(posts))
+
+	for _, p := range posts {
+		if (featured || h.Featured) && !p.IsFeatured {
+			continue
+		}
+
+		if h.Tag != "" && !p.HasTag(h.Tag) {
+			continue
+		}
+
+		selected = append(selected, p)
+	}
+
+	switch h.Sort {
+	case sortUpdated:
+		sort.SliceStable(selected, func(i, j int) bool {
+			return selected[i].LatestTS() > selected[j].LatestTS()
+		})
+	case sortTitle:
+		sort.SliceStable(selected, func(i, j int) bool {
+			return strings.ToLower(selected[i].Title) < strings.ToLower(selected[j].Title)
+		})
+	}
+
+	if limit > 0 {
+		selected = selected[:min(limit, len(selected))]
+	}
+
+	return selected
+}
@@ -0,0 +1,326 @@
+package app
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"gingersnap/app/utils"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Type: language
+//
+//
+// ------------------------------------------------------------------
+
+// language stores the settings of a site language.
+//
+// The first language is the default language, which is served
+// at the site root. The other languages are served under
+// `/<code>/`, with their own homepage, posts and categories.
+//
+// ex:
+//
+//	"languages": [
+//		{"code": "en", "name": "English", "locale": "en_US"},
+//		{"code": "es", "name": "Español", "locale": "es_ES", "tagline": "La forma rápida de crear sitios"}
+//	]
+//
+// .
+type language struct {
+	// The language code, used in the urls and the hreflang links, ex: "es"
+	Code string `json:"code"`
+
+	// The display name, ex: "Español" (default is the code)
+	Name string `json:"name"`
+
+	// The open graph locale, ex: "es_ES" (default is the code)
+	Locale string `json:"locale"`
+
+	// The site settings for the language (default is the site settings)
+	Tagline     string            `json:"tagline"`
+	Description string            `json:"description"`
+	Homepage    []homepageSection `json:"homepage"`
+	NavbarLinks []siteLink        `json:"navbarLinks"`
+	FooterLinks []siteLink        `json:"footerLinks"`
+
+	// The site title for the language
+	Title string
+
+	// If the language is served at the site root
+	IsDefault bool
+
+	// The url path prefix, ex: "/es". It is empty for the default language.
+	Prefix string
+
+	// The translated UI strings
+	messages messages
+}
+
+// The default language, if no languages are configured.
+var fallbackLanguage = language{Code: "en", Name: "English", Locale: "en_US"}
+
+// languageCodePattern matches the supported language codes, ex: "en", "pt-BR".
+var languageCodePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// validateLanguages sets the default language settings, and
+// checks that the language codes are valid and unique.
+// .
+func (c *config) validateLanguages() error {
+	if len(c.Languages) == 0 {
+		lang := fallbackLanguage
+		c.Languages = []*language{&lang}
+	}
+
+	seen := make(map[string]bool, len(c.Languages))
+
+	for i, lang := range c.Languages {
+		if !languageCodePattern.MatchString(lang.Code) {
+			return fmt.Errorf("could not load language [%s]", lang.Code)
+		}
+
+		if seen[lang.Code] {
+			return fmt.Errorf("could not load language [%s]: duplicate code", lang.Code)
+		}
+		seen[lang.Code] = true
+
+		if lang.Name == "" {
+			lang.Name = lang.Code
+		}
+
+		if lang.Locale == "" {
+			lang.Locale = lang.Code
+		}
+
+		if lang.Tagline == "" {
+			lang.Tagline = c.Site.Tagline
+		}
+
+		if lang.Description == "" {
+			lang.Description = c.Site.Description
+		}
+
+		if lang.Homepage == nil {
+			lang.Homepage = c.Homepage
+		}
+
+		if lang.NavbarLinks == nil {
+			lang.NavbarLinks = c.NavbarLinks
+		}
+
+		if lang.FooterLinks == nil {
+			lang.FooterLinks = c.FooterLinks
+		}
+
+		lang.Title = fmt.Sprintf("%s - %s", c.Site.Name, lang.Tagline)
+		lang.IsDefault = i == 0
+
+		if !lang.IsDefault {
+			lang.Prefix = "/" + lang.Code
+		}
+	}
+
+	return nil
+}
+
+// defaultLanguage returns the language served at the site root.
+// .
+func (c *config) defaultLanguage() *language {
+	return c.Languages[0]
+}
+
+// isMultilingual reports if the site has more than one language.
+// .
+func (c *config) isMultilingual() bool {
+	return len(c.Languages) > 1
+}
+
+// homeRoute returns the url path of the language's homepage.
+//
+// ex: "/", "/es/"
+// .
+func (l *language) homeRoute() string {
+	return l.Prefix + "/"
+}
+
+// label prefixes the slug with the language code, except for the
+// default language. This is useful for error messages.
+//
+// ex: "some-slug", "es/some-slug"
+// .
+func (l *language) label(slug string) string {
+	if l.IsDefault {
+		return slug
+	}
+	return fmt.Sprintf("%s/%s", l.Code, slug)
+}
+
+// sitemapRoute returns the url path of the language's xml sitemap.
+// It is only used for multilingual sites.
+//
+// ex: "/sitemap-es.xml"
+// .
+func (l *language) sitemapRoute() string {
+	return fmt.Sprintf("/sitemap-%s.xml", l.Code)
+}
+
+// ------------------------------------------------------------------
+//
+//
+// Type: messages
+//
+//
+// ------------------------------------------------------------------
+
+// messages stores the translated UI strings of a language, by key.
+// Some strings are format strings, ex: "Total %d Posts".
+// .
+type messages map[string]string
+
+// The language of the built-in messages, which are the
+// fallback for the missing keys of the other languages.
+const fallbackMessages = "en"
+
+// loadMessages reads the messages for the language code.
+//
+// The messages are merged in order: the built-in English messages,
+// the built-in messages for the language (if any), and the
+// project's message file `<dir>/<code>.json` (if any).
+// .
+func loadMessages(efs embed.FS, code, dir string) (messages, error) {
+	m := messages{}
+
+	// Read the built-in messages.
+	for _, name := range []string{fallbackMessages, code} {
+		data, err := efs.ReadFile(fmt.Sprintf("assets/i18n/%s.json", name))
+		if err != nil {
+			continue
+		}
+
+		if err := m.merge(data, name, name != fallbackMessages); err != nil {
+			return nil, err
+		}
+	}
+
+	// Read the project's messages.
+	filePath := filepath.Join(dir, code+".json")
+	if !utils.Exists(filePath) {
+		return m, nil
+	}
+
+	data, err := utils.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, m.merge(data, code, true)
+}
+
+// merge adds the messages from the json data. In strict mode, only the
+// existing keys are accepted, to catch misspelled keys.
+// .
+func (m messages) merge(data []byte, code string, strict bool) error {
+	parsed := map[string]string{}
+
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return fmt.Errorf("could not load messages [%s]: %w", code, err)
+	}
+
+	for key, value := range parsed {
+		if _, ok := m[key]; !ok && strict {
+			return fmt.Errorf("could not load messages [%s]: unknown key [%s]", code, key)
+		}
+		m[key] = value
+	}
+
+	return nil
+}
+
+// translate returns the message for the key, formatted with the args.
+// If the message does not exist, then the key is returned.
+// .
+func (m messages) translate(key string, args ...any) string {
+	msg, ok := m[key]
+	if !ok {
+		return key
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+
+	return msg
+}
+
+// ------------------------------------------------------------------
+//
+//
+// Type: alternate
+//
+//
+// ------------------------------------------------------------------
+
+// alternate is a link to a translation of a page,
+// used in the hreflang links and the xml sitemaps.
+// .
+type alternate struct {
+	// The language code, or "x-default" for the default language
+	Lang string
+
+	// The absolute url of the translation
+	Url string
+}
+
+// alternates returns the hreflang links for the routes of a page,
+// by language code, in the order of the configured languages.
+// A page without translations has no alternates.
+// .
+func (g *Gingersnap) alternates(routesByLang map[string]string) []alternate {
+	if len(routesByLang) < 2 {
+		return nil
+	}
+
+	alts := make([]alternate, 0, len(routesByLang)+1)
+
+	for _, lang := range g.config.Languages {
+		if route, ok := routesByLang[lang.Code]; ok {
+			alts = append(alts, alternate{Lang: lang.Code, Url: g.config.Site.Url + route})
+		}
+	}
+
+	if route, ok := routesByLang[g.config.defaultLanguage().Code]; ok {
+		alts = append(alts, alternate{Lang: "x-default", Url: g.config.Site.Url + route})
+	}
+
+	return alts
+}
+
+// postAlternates returns the hreflang links between a post and its translations.
+// .
+func (g *Gingersnap) postAlternates(p *post) []alternate {
+	routesByLang := map[string]string{p.Lang.Code: p.Route()}
+
+	for _, t := range p.Translations {
+		routesByLang[t.Lang.Code] = t.Route()
+	}
+
+	return g.alternates(routesByLang)
+}
+
+// languageAlternates returns the hreflang links between the
+// pages which exist in every language, like the homepages.
+// .
+func (g *Gingersnap) languageAlternates(route func(*language) string) []alternate {
+	routesByLang := make(map[string]string, len(g.config.Languages))
+
+	for _, lang := range g.config.Languages {
+		routesByLang[lang.Code] = route(lang)
+	}
+
+	return g.alternates(routesByLang)
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Logging
+//
+//
+// ------------------------------------------------------------------
+
+// The log formats.
+const logFormatText = "text"
+const logFormatJson = "json"
+
+// NewLogger returns a structured logger, which writes
+// "text" or "json" records at or above the level.
+//
+// ex:
+//
+//	logger, err := app.NewLogger(os.Stderr, "json", "debug")
+//
+// .
+func NewLogger(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("could not load log level [%s]", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	switch format {
+	case logFormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case logFormatJson:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+
+	return nil, fmt.Errorf("could not load log format [%s]", format)
+}
+
+// newLogger returns the engine's logger, or a text logger on stderr.
+// .
+func (g *Gingersnap) newLogger() *slog.Logger {
+	if g.Logger != nil {
+		return g.Logger
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, nil))
+}
+
+// The header for the request id, which is
+// kept if the client or proxy sets it.
+const requestIdHeader = "X-Request-Id"
+
+// newRequestId returns a random id for a request, ex: "9f86d081a2b3c4d5".
+// .
+func newRequestId() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
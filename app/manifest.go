@@ -0,0 +1,82 @@
+package app
+
+import (
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Type: buildManifest
+//
+//
+// ------------------------------------------------------------------
+
+// The name of the build manifest, in the export directory.
+const buildManifestName = ".gingersnap"
+
+// buildManifest describes an export, so that a deployed site
+// can be traced back to its content and to the gingersnap build.
+//
+// ex:
+//
+//	{
+//	  "buildTime": "2024-05-04T10:12:31Z",
+//	  "contentHash": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b",
+//	  "version": "ad9280c159",
+//	  "pages": 41
+//	}
+//
+// .
+type buildManifest struct {
+	// The time of the export, in UTC
+	BuildTime time.Time `json:"buildTime"`
+
+	// The git hash of the content repository (empty if it is not a git repository)
+	ContentHash string `json:"contentHash"`
+
+	// The gingersnap version, ex: the git hash of the gingersnap build
+	Version string `json:"version"`
+
+	// The number of exported html pages
+	Pages int `json:"pages"`
+}
+
+// buildManifest returns the build manifest for the exported files.
+// .
+func (e *exporter) buildManifest(files []*exportFile) buildManifest {
+	m := buildManifest{
+		BuildTime:   time.Now().UTC().Truncate(time.Second),
+		ContentHash: gitHash(e.contentPath),
+		Version:     e.version,
+	}
+
+	if m.Version == "" {
+		m.Version = "dev"
+	}
+
+	for _, f := range files {
+		if f.isHTML() {
+			m.Pages++
+		}
+	}
+
+	return m
+}
+
+// gitHash returns the hash of the git commit checked out in the
+// directory, or an empty string if it is not in a git repository.
+// .
+func gitHash(dir string) string {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
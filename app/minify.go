@@ -0,0 +1,568 @@
+package app
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Minification
+//
+//
+// ------------------------------------------------------------------
+
+// minifiers maps file extensions to their minify functions.
+var minifiers = map[string]func([]byte) []byte{
+	".html": minifyHTML,
+	".css":  minifyCSS,
+	".xml":  minifyXML,
+}
+
+// Elements whose content must be kept verbatim. The content
+// of <style> and <script> elements is minified separately.
+var rawElements = map[string]bool{
+	"pre":      true,
+	"code":     true,
+	"textarea": true,
+}
+
+// Block-level elements. Whitespace next to these
+// elements does not affect the rendered page.
+var blockElements = map[string]bool{
+	"!doctype": true, "html": true, "head": true, "body": true, "meta": true,
+	"link": true, "title": true, "script": true, "style": true, "noscript": true,
+	"header": true, "footer": true, "nav": true, "main": true, "article": true,
+	"section": true, "aside": true, "div": true, "p": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "ul": true, "ol": true, "li": true,
+	"dl": true, "dt": true, "dd": true, "table": true, "thead": true, "tbody": true,
+	"tfoot": true, "tr": true, "td": true, "th": true, "caption": true, "figure": true,
+	"figcaption": true, "blockquote": true, "details": true, "summary": true,
+	"hr": true, "br": true, "pre": true, "form": true, "fieldset": true,
+	"legend": true, "video": true, "audio": true, "source": true, "iframe": true,
+}
+
+// minifyHTML removes comments and collapses whitespace in an HTML document.
+// The content of <pre>, <code> and <textarea> elements is kept verbatim,
+// and the content of <style> and <script> elements is minified separately.
+// .
+func minifyHTML(src []byte) []byte {
+	buf := new(bytes.Buffer)
+	buf.Grow(len(src))
+
+	s := string(src)
+
+	// The name of the last written tag, and the pending whitespace
+	// which will be written if the next tag is not a block element.
+	lastTag := ""
+	pendingSpace := false
+
+	for len(s) > 0 {
+
+		// Handle text content.
+		if s[0] != '<' {
+			i := strings.IndexByte(s, '<')
+			if i < 0 {
+				i = len(s)
+			}
+			text := s[:i]
+			s = s[i:]
+
+			collapsed := collapseSpace(text)
+			if strings.TrimSpace(collapsed) == "" {
+				pendingSpace = pendingSpace || collapsed != ""
+				continue
+			}
+
+			// Leading whitespace is dropped after a block element.
+			if strings.HasPrefix(collapsed, " ") {
+				collapsed = collapsed[1:]
+				pendingSpace = true
+			}
+
+			if pendingSpace && !blockElements[lastTag] {
+				buf.WriteByte(' ')
+			}
+			pendingSpace = false
+
+			// Trailing whitespace is deferred until the next tag is known.
+			if trimmed := strings.TrimRight(collapsed, " "); trimmed != collapsed {
+				collapsed = trimmed
+				pendingSpace = true
+			}
+
+			buf.WriteString(collapsed)
+			lastTag = ""
+			continue
+		}
+
+		// Handle comments. Conditional comments are kept.
+		if strings.HasPrefix(s, "<!--") {
+			end := strings.Index(s, "-->")
+			if end < 0 {
+				end = len(s) - 3
+			}
+			comment := s[:end+3]
+			s = s[end+3:]
+
+			if strings.HasPrefix(comment, "<!--[if") {
+				buf.WriteString(comment)
+			}
+			continue
+		}
+
+		// Handle tags.
+		end := tagEnd(s)
+		tag := s[:end]
+		s = s[end:]
+
+		name, closing := tagName(tag)
+
+		if pendingSpace && !blockElements[name] && !blockElements[lastTag] {
+			buf.WriteByte(' ')
+		}
+		pendingSpace = false
+
+		buf.WriteString(collapseTag(tag))
+		lastTag = name
+
+		if closing {
+			continue
+		}
+
+		// Copy the content of raw elements verbatim.
+		if rawElements[name] {
+			i := indexFold(s, "</"+name)
+			if i < 0 {
+				i = len(s)
+			}
+			buf.WriteString(s[:i])
+			s = s[i:]
+			continue
+		}
+
+		// Minify the content of style and script elements.
+		if name == "style" || name == "script" {
+			i := indexFold(s, "</"+name)
+			if i < 0 {
+				i = len(s)
+			}
+			content := s[:i]
+			s = s[i:]
+
+			if name == "style" {
+				buf.Write(minifyCSS([]byte(content)))
+			} else if !strings.Contains(tag, "type=") || strings.Contains(tag, "javascript") || strings.Contains(tag, "json") {
+				buf.WriteString(minifyJS(content))
+			} else {
+				buf.WriteString(content)
+			}
+		}
+	}
+
+	return bytes.TrimSpace(buf.Bytes())
+}
+
+// tagEnd returns the index after the closing `>` of the tag
+// at the start of the string, respecting quoted attributes.
+// .
+func tagEnd(s string) int {
+	var quote byte
+
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i + 1
+		}
+	}
+
+	return len(s)
+}
+
+// tagName returns the lowercase name of the tag, and
+// if it is a closing tag.
+//
+// ex: `<DIV class="x">` => "div", false
+// .
+func tagName(tag string) (string, bool) {
+	t := strings.TrimPrefix(tag, "<")
+	closing := strings.HasPrefix(t, "/")
+	t = strings.TrimPrefix(t, "/")
+
+	end := strings.IndexAny(t, " \t\r\n/>")
+	if end < 0 {
+		end = len(t)
+	}
+
+	return strings.ToLower(t[:end]), closing
+}
+
+// collapseTag collapses the whitespace within a tag,
+// leaving the quoted attribute values untouched.
+// .
+func collapseTag(tag string) string {
+	var b strings.Builder
+	var quote byte
+	space := false
+
+	for i := 0; i < len(tag); i++ {
+		c := tag[i]
+
+		if quote != 0 {
+			b.WriteByte(c)
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		if isSpace(c) {
+			space = true
+			continue
+		}
+
+		if space && c != '>' && !(c == '/' && i+1 < len(tag) && tag[i+1] == '>') {
+			b.WriteByte(' ')
+		}
+		space = false
+
+		if c == '"' || c == '\'' {
+			quote = c
+		}
+		b.WriteByte(c)
+	}
+
+	return b.String()
+}
+
+// collapseSpace replaces each run of whitespace with a single space.
+// .
+func collapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+
+	for i := 0; i < len(s); i++ {
+		if isSpace(s[i]) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteByte(s[i])
+	}
+
+	if space {
+		b.WriteByte(' ')
+	}
+
+	return b.String()
+}
+
+// indexFold returns the index of the first case-insensitive
+// instance of substr in s, or -1 if it is not present.
+// .
+func indexFold(s, substr string) int {
+	return strings.Index(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// minifyJS trims the indentation of every line, and removes blank lines.
+// Line breaks are kept, so that automatic semicolon insertion still works.
+// Strings, template literals, regular expressions and comments are kept
+// verbatim, so multi-line strings are unchanged.
+// .
+func minifyJS(src string) string {
+	m := &jsMinifier{src: src}
+	m.buf.Grow(len(src))
+	m.code(false)
+
+	return strings.TrimSpace(m.buf.String())
+}
+
+// jsMinifier scans a script, and writes the minified script.
+// .
+type jsMinifier struct {
+	src string
+	i   int
+	buf bytes.Buffer
+}
+
+// code copies the code until the end of the script, or until the
+// closing brace of a template literal expression, ex: `${a}`.
+// .
+func (m *jsMinifier) code(inExpr bool) {
+	depth := 0
+
+	for m.i < len(m.src) {
+		c := m.src[m.i]
+		rest := m.src[m.i:]
+
+		switch {
+		case c == '\n':
+			m.newline()
+			continue
+		case c == '"' || c == '\'':
+			m.quoted(c)
+			continue
+		case c == '`':
+			m.template()
+			continue
+		case strings.HasPrefix(rest, "//"):
+			m.copyTo(strings.IndexByte(rest, '\n'))
+			continue
+		case strings.HasPrefix(rest, "/*"):
+			if end := strings.Index(rest[2:], "*/"); end >= 0 {
+				m.copyTo(end + 4)
+			} else {
+				m.copyTo(-1)
+			}
+			continue
+		case c == '/' && m.regexAllowed():
+			m.regex()
+			continue
+		case c == '{':
+			depth++
+		case c == '}':
+			if inExpr && depth == 0 {
+				return
+			}
+			depth--
+		}
+
+		m.buf.WriteByte(c)
+		m.i++
+	}
+}
+
+// newline ends the line without its trailing whitespace, and
+// skips the indentation and the blank lines which follow.
+// .
+func (m *jsMinifier) newline() {
+	b := m.buf.Bytes()
+	n := len(b)
+	for n > 0 && (b[n-1] == ' ' || b[n-1] == '\t' || b[n-1] == '\r') {
+		n--
+	}
+	m.buf.Truncate(n)
+
+	if n > 0 && b[n-1] != '\n' {
+		m.buf.WriteByte('\n')
+	}
+
+	for m.i < len(m.src) && isSpace(m.src[m.i]) {
+		m.i++
+	}
+}
+
+// quoted copies a string, until its closing quote.
+// .
+func (m *jsMinifier) quoted(quote byte) {
+	end := m.i + 1
+
+	for end < len(m.src) && m.src[end] != quote && m.src[end] != '\n' {
+		if m.src[end] == '\\' {
+			end++
+		}
+		end++
+	}
+
+	m.copyTo(end + 1 - m.i)
+}
+
+// template copies a template literal. The code of its
+// expressions is minified, ex: `${a}`.
+// .
+func (m *jsMinifier) template() {
+	m.buf.WriteByte('`')
+	m.i++
+
+	for m.i < len(m.src) {
+		switch {
+		case m.src[m.i] == '\\':
+			m.copyTo(2)
+		case m.src[m.i] == '`':
+			m.copyTo(1)
+			return
+		case strings.HasPrefix(m.src[m.i:], "${"):
+			m.copyTo(2)
+			m.code(true)
+			m.copyTo(1)
+		default:
+			m.copyTo(1)
+		}
+	}
+}
+
+// regex copies a regular expression literal, until its closing slash.
+// .
+func (m *jsMinifier) regex() {
+	end := m.i + 1
+	inClass := false
+
+	for end < len(m.src) && m.src[end] != '\n' {
+		c := m.src[end]
+		if c == '\\' {
+			end++
+		} else if c == '[' {
+			inClass = true
+		} else if c == ']' {
+			inClass = false
+		} else if c == '/' && !inClass {
+			break
+		}
+		end++
+	}
+
+	m.copyTo(end + 1 - m.i)
+}
+
+// regexAllowed reports if a slash starts a regular expression,
+// rather than a division, from the previous character.
+// .
+func (m *jsMinifier) regexAllowed() bool {
+	b := bytes.TrimRight(m.buf.Bytes(), " \t")
+	return len(b) == 0 || strings.IndexByte("(,=:[!&|?{};+-*%<>~^\n", b[len(b)-1]) >= 0
+}
+
+// copyTo copies the next n bytes of the script, or the
+// rest of the script if n is negative or too large.
+// .
+func (m *jsMinifier) copyTo(n int) {
+	end := m.i + n
+	if n < 0 || end > len(m.src) {
+		end = len(m.src)
+	}
+
+	m.buf.WriteString(m.src[m.i:end])
+	m.i = end
+}
+
+// minifyCSS removes comments and unnecessary whitespace from a stylesheet.
+// License comments (starting with `/*!`) and strings are kept.
+// .
+func minifyCSS(src []byte) []byte {
+	css := string(src)
+	buf := new(bytes.Buffer)
+	buf.Grow(len(css))
+
+	buf.WriteString(licenseComments(css))
+	css = stripCSSComments(css)
+
+	// Track if the cursor is within declarations, where the
+	// whitespace after a colon can be removed. In selectors,
+	// the whitespace before a colon is a descendant combinator.
+	blocks := make([]bool, 0, 4)
+	prelude := 0
+
+	inDecl := func() bool {
+		return len(blocks) > 0 && blocks[len(blocks)-1]
+	}
+
+	space := false
+	last := byte(0)
+
+	for i := 0; i < len(css); i++ {
+		c := css[i]
+
+		// Copy strings verbatim.
+		if c == '"' || c == '\'' {
+			if space && !isCSSPunct(last, inDecl()) {
+				buf.WriteByte(' ')
+			}
+			space = false
+
+			j := i + 1
+			for j < len(css) && css[j] != c {
+				if css[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(css))
+			buf.WriteString(css[i:j])
+			last = c
+			i = j - 1
+			continue
+		}
+
+		if isSpace(c) {
+			space = true
+			continue
+		}
+
+		switch c {
+		case '{':
+			// Rules and descriptor at-rules contain declarations,
+			// while grouping at-rules (ex: `@media`) contain rules.
+			p := strings.TrimSpace(css[prelude:i])
+			isDecl := !strings.HasPrefix(p, "@") || strings.HasPrefix(p, "@font-face") || strings.HasPrefix(p, "@page")
+			blocks = append(blocks, isDecl)
+			prelude = i + 1
+		case '}':
+			if len(blocks) > 0 {
+				blocks = blocks[:len(blocks)-1]
+			}
+			prelude = i + 1
+
+			// Remove the last semicolon in the block.
+			if last == ';' {
+				buf.Truncate(buf.Len() - 1)
+			}
+		case ';':
+			if !inDecl() {
+				prelude = i + 1
+			}
+		}
+
+		if space && !isCSSPunct(last, inDecl()) && !isCSSPunct(c, inDecl()) {
+			buf.WriteByte(' ')
+		}
+		space = false
+
+		buf.WriteByte(c)
+		last = c
+	}
+
+	return bytes.TrimSpace(buf.Bytes())
+}
+
+// isCSSPunct reports if whitespace next to the character can be removed.
+// The colon is only included within declarations.
+// .
+func isCSSPunct(c byte, inDecl bool) bool {
+	switch c {
+	case '{', '}', ';', ',', '>', 0:
+		return true
+	case ':':
+		return inDecl
+	}
+	return false
+}
+
+// xmlSpacePattern matches the whitespace between XML tags.
+var xmlSpacePattern = regexp.MustCompile(`>\s+<`)
+
+// xmlCommentPattern matches XML comments.
+var xmlCommentPattern = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+// minifyXML removes comments and the whitespace between tags.
+// .
+func minifyXML(src []byte) []byte {
+	src = xmlCommentPattern.ReplaceAll(src, nil)
+	src = xmlSpacePattern.ReplaceAll(src, []byte("><"))
+	return bytes.TrimSpace(src)
+}
@@ -0,0 +1,47 @@
+package app
+
+import "testing"
+
+func TestMinifyJS(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			"indentation",
+			"\n  if (a) {\n    b();\n\n  }  \n",
+			"if (a) {\nb();\n}",
+		},
+		{
+			"template literal",
+			"  const s = `a\n    b ${ x.map(y => `\n  ${y}`) }\n  c`;\n  go(s);",
+			"const s = `a\n    b ${ x.map(y => `\n  ${y}`) }\n  c`;\ngo(s);",
+		},
+		{
+			"continued string",
+			"  const s = \"a \\\n    b\";\n  go(s);",
+			"const s = \"a \\\n    b\";\ngo(s);",
+		},
+		{
+			"regex and comments",
+			"  const r = /['`]/g; // it's\n  /* a `b`\n    c */\n  go(r);",
+			"const r = /['`]/g; // it's\n/* a `b`\n    c */\ngo(r);",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := minifyJS(tt.src); got != tt.want {
+			t.Errorf("%s: got\n%q\nwant\n%q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMinifyHTMLRawElements(t *testing.T) {
+	src := "<div>\n  <p>Run <code>go  build\n  ./...</code> now</p>\n  <pre>a\n  b</pre>\n</div>"
+	want := "<div><p>Run <code>go  build\n  ./...</code> now</p><pre>a\n  b</pre></div>"
+
+	if got := string(minifyHTML([]byte(src))); got != want {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+}
@@ -1,6 +1,10 @@
 package app
 
-import "fmt"
+import (
+	"fmt"
+	"path"
+	"strings"
+)
 
 // ------------------------------------------------------------------
 //
@@ -58,8 +62,29 @@ type post struct {
 	// The updated date, as a UNIX timestamp
 	UpdatedTS int
 
-	// The index of the post in the `PostsByCategory` map.
-	idxCategory int
+	// The previous url paths, which redirect to the post
+	Aliases []string
+
+	// The post tags, as slugs
+	Tags []string
+
+	// The slugs of the related posts, pinned before the ranked ones
+	Related []string
+
+	// The post language
+	Lang *language
+
+	// The key which links the translations of a post
+	TranslationKey string
+
+	// The translations of the post, in the order of the site languages
+	Translations []*post
+
+	// The pinned related posts, resolved from the slugs.
+	relatedPinned []*post
+
+	// The url path, from the permalink pattern.
+	route string
 }
 
 // LatestTS returns the Post's latest timestamped date.
@@ -71,11 +96,25 @@ func (p *post) LatestTS() int {
 	return p.PubdateTS
 }
 
+// HasTag reports if the post has the tag slug.
+// .
+func (p *post) HasTag(tag string) bool {
+	for _, t := range p.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
 // Route returns the url path for the Post.
 //
 // ex: "/post-slug/"
 // .
 func (p *post) Route() string {
+	if p.route != "" {
+		return p.route
+	}
 	return fmt.Sprintf("/%s/", p.Slug)
 }
 
@@ -92,6 +131,9 @@ func (p *post) Route() string {
 type category struct {
 	Slug  string
 	Title string
+
+	// The url path, from the category base.
+	route string
 }
 
 // IsEmpty reports if the category is empty.
@@ -105,9 +147,124 @@ func (c category) IsEmpty() bool {
 // ex: "/category/some-slug/"
 // .
 func (c category) Route() string {
+	if c.route != "" {
+		return c.route
+	}
 	return fmt.Sprintf("/category/%s/", c.Slug)
 }
 
+// ------------------------------------------------------------------
+//
+//
+// Type: redirect
+//
+//
+// ------------------------------------------------------------------
+
+// redirect represents a permanent redirect from an old url path,
+// either from a post alias or from the config redirects.
+// .
+type redirect struct {
+	// The old url path, ex: "/old-slug/"
+	From string
+
+	// The new url path or absolute url, ex: "/new-slug/"
+	To string
+}
+
+// Route returns the url path for the redirect.
+// .
+func (r redirect) Route() string {
+	return r.From
+}
+
+// reservedRoutes are the url paths of the built-in routes,
+// which cannot be used for redirects.
+var reservedRoutes = []string{
+	"/", "/styles.css", "/highlight.css", "/sitemap/", "/sitemap.xml",
+	"/robots.txt", "/CNAME", "/404/", "/themes/", "/media/",
+}
+
+// aliasRoute normalizes an alias into a url path.
+// Paths without a file extension get a trailing slash.
+//
+// ex: "old-slug" => "/old-slug/"
+// .
+func aliasRoute(alias string) string {
+	p := "/" + strings.Trim(alias, "/")
+	if path.Ext(p) == "" && p != "/" {
+		p += "/"
+	}
+	return p
+}
+
+// ------------------------------------------------------------------
+//
+//
+// Type: archiveYear
+//
+//
+// ------------------------------------------------------------------
+
+// archiveYear represents the posts published in a year,
+// grouped by month.
+// .
+type archiveYear struct {
+	Year   int
+	Posts  []*post
+	Months []archiveMonth
+
+	// The url path, with the language prefix.
+	route string
+}
+
+// Route returns the url path for the archive year.
+//
+// ex: "/archive/2023/"
+// .
+func (a archiveYear) Route() string {
+	return a.route
+}
+
+// ------------------------------------------------------------------
+//
+//
+// Type: archiveMonth
+//
+//
+// ------------------------------------------------------------------
+
+// archiveMonth represents the posts published in a month.
+// .
+type archiveMonth struct {
+	Year  int
+	Month int
+	Posts []*post
+
+	// The translated month name, ex: "June"
+	Name string
+
+	// The url path, with the language prefix.
+	route string
+}
+
+// Route returns the url path for the archive month.
+//
+// ex: "/archive/2023/06/"
+// .
+func (a archiveMonth) Route() string {
+	return a.route
+}
+
+// archiveRoute returns the url path of the archive
+// for the language prefix.
+//
+// ex: "/archive/", "/es/archive/"
+// .
+func archiveRoute(prefix string) string {
+	return prefix + "/archive/"
+}
+
 // ------------------------------------------------------------------
 //
 //
@@ -125,6 +282,15 @@ func (c category) Route() string {
 type section struct {
 	Category category
 	Posts    []*post
+
+	// The section heading
+	Title string
+
+	// The post layout
+	Display display
+
+	// The archive years, for the "$archive" section
+	Archive []archiveYear
 }
 
 // A homepage section which represents all latest posts.
@@ -136,6 +302,9 @@ const sectionFeatured = "$featured"
 // A homepage section which represents all posts.
 const sectionAll = "$all"
 
+// A homepage section which represents the archive by year and month.
+const sectionArchive = "$archive"
+
 // ------------------------------------------------------------------
 //
 //
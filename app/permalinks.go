@@ -0,0 +1,142 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Type: permalinks
+//
+//
+// ------------------------------------------------------------------
+
+// permalinks stores the url patterns for posts, pages and categories.
+//
+// ex:
+//
+//	"permalinks": {
+//		"post": "/:year/:month/:slug/",
+//		"categoryBase": "topics"
+//	}
+//
+// .
+type permalinks struct {
+	// The blog post pattern (default "/:slug/")
+	// Tokens: :year, :month, :day, :category, :slug
+	Post string `json:"post"`
+
+	// The standalone post (page) pattern (default "/:slug/")
+	// Tokens: :slug
+	Page string `json:"page"`
+
+	// The path prefix for categories (default "category")
+	CategoryBase string `json:"categoryBase"`
+}
+
+// The default permalink settings.
+const defaultPostPermalink = "/:slug/"
+const defaultPagePermalink = "/:slug/"
+const defaultCategoryBase = "category"
+
+// permalinkTokenPattern matches the tokens within a permalink pattern.
+var permalinkTokenPattern = regexp.MustCompile(`:([a-z]+)`)
+
+// validate sets the default patterns, and checks
+// that the patterns only use the supported tokens.
+// .
+func (pl *permalinks) validate() error {
+	if pl.Post == "" {
+		pl.Post = defaultPostPermalink
+	}
+
+	if pl.Page == "" {
+		pl.Page = defaultPagePermalink
+	}
+
+	if pl.CategoryBase == "" {
+		pl.CategoryBase = defaultCategoryBase
+	}
+
+	if err := validatePermalink(pl.Post, "year", "month", "day", "category", "slug"); err != nil {
+		return err
+	}
+
+	if err := validatePermalink(pl.Page, "slug"); err != nil {
+		return err
+	}
+
+	pl.CategoryBase = strings.Trim(pl.CategoryBase, "/")
+
+	if pl.CategoryBase == "" || strings.Contains(pl.CategoryBase, ":") {
+		return fmt.Errorf("could not load category base [%s]", pl.CategoryBase)
+	}
+
+	return nil
+}
+
+// validatePermalink checks that the pattern is a path with
+// a trailing slash, which contains the slug and the given tokens only.
+// .
+func validatePermalink(pattern string, tokens ...string) error {
+	if !strings.HasPrefix(pattern, "/") || !strings.HasSuffix(pattern, "/") {
+		return fmt.Errorf("could not load permalink [%s]: must start and end with a slash", pattern)
+	}
+
+	if !strings.Contains(pattern, ":slug") {
+		return fmt.Errorf("could not load permalink [%s]: must contain :slug", pattern)
+	}
+
+	for _, match := range permalinkTokenPattern.FindAllStringSubmatch(pattern, -1) {
+		supported := false
+		for _, token := range tokens {
+			supported = supported || match[1] == token
+		}
+
+		if !supported {
+			return fmt.Errorf("could not load permalink [%s]: unsupported token :%s", pattern, match[1])
+		}
+	}
+
+	return nil
+}
+
+// postRoute returns the url path for the post.
+//
+// ex: "/:year/:month/:slug/"  =>  "/2023/06/post-slug/"
+// .
+func (pl permalinks) postRoute(p *post) string {
+	if p.IsPage {
+		return expandPermalink(pl.Page, map[string]string{"slug": p.Slug})
+	}
+
+	date := time.Unix(int64(p.PubdateTS), 0).UTC()
+
+	return expandPermalink(pl.Post, map[string]string{
+		"year":     date.Format("2006"),
+		"month":    date.Format("01"),
+		"day":      date.Format("02"),
+		"category": p.Category.Slug,
+		"slug":     p.Slug,
+	})
+}
+
+// categoryRoute returns the url path for the category.
+//
+// ex: "/category/some-slug/"
+// .
+func (pl permalinks) categoryRoute(slug string) string {
+	return fmt.Sprintf("/%s/%s/", pl.CategoryBase, slug)
+}
+
+// expandPermalink replaces the tokens of the pattern with their values.
+// .
+func expandPermalink(pattern string, values map[string]string) string {
+	return permalinkTokenPattern.ReplaceAllStringFunc(pattern, func(token string) string {
+		return values[token[1:]]
+	})
+}
@@ -2,11 +2,12 @@ package app
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/yuin/goldmark"
-	high "github.com/yuin/goldmark-highlighting/v2"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/renderer/html"
@@ -36,17 +37,32 @@ type processor struct {
 	// A slice of markdown posts filepaths to process
 	filePaths []string
 
-	// The collected Posts and Categories
-	postsBySlug      map[string]*post
-	categoriesBySlug map[string]category
+	// The site languages. The first one is the default language.
+	languages []*language
+
+	// The collected Posts and Categories, by language code
+	postsByLang      map[string]map[string]*post
+	categoriesByLang map[string]map[string]category
+
+	// The url patterns for posts, pages and categories
+	permalinks permalinks
+
+	// The redirects from the config, by old url path
+	redirects map[string]string
+
+	// The collected redirects, by old url path
+	redirectsByRoute map[string]redirect
+
+	// The time spent processing each markdown file
+	timings []postTiming
 }
 
-func newProcessor(filePaths []string) *processor {
-	return &processor{
+func newProcessor(filePaths []string, languages []*language, permalinks permalinks, redirects map[string]string) *processor {
+	pr := &processor{
 		//
 		markdown: goldmark.New(
 			goldmark.WithExtensions(
-				high.NewHighlighting(high.WithStyle("tango")),
+				newHighlighting(),
 				&frontmatter.Extender{
 					Mode: frontmatter.SetMetadata,
 				},
@@ -63,18 +79,38 @@ func newProcessor(filePaths []string) *processor {
 		//
 		filePaths: filePaths,
 		//
-		postsBySlug: make(map[string]*post, 20),
+		languages: languages,
+		//
+		postsByLang: make(map[string]map[string]*post, len(languages)),
+		//
+		categoriesByLang: make(map[string]map[string]category, len(languages)),
+		//
+		permalinks: permalinks,
+		//
+		redirects: redirects,
 		//
-		categoriesBySlug: make(map[string]category, 20),
+		redirectsByRoute: make(map[string]redirect, len(redirects)),
 	}
+
+	for _, lang := range languages {
+		pr.postsByLang[lang.Code] = make(map[string]*post, 20)
+		pr.categoriesByLang[lang.Code] = make(map[string]category, 20)
+	}
+
+	return pr
 }
 
 // The Process method parses all markdown posts and
-// stores it in memory.
+// stores it in memory. It stops early if the context is canceled.
 // .
-func (pr *processor) process() error {
+func (pr *processor) process(ctx context.Context) error {
 
 	for _, filePath := range pr.filePaths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		start := time.Now()
 
 		// Read the markdown file.
 		fileBytes, err := utils.ReadFile(filePath)
@@ -86,9 +122,22 @@ func (pr *processor) process() error {
 		if err := pr.processPost(fileBytes); err != nil {
 			return err
 		}
+
+		pr.timings = append(pr.timings, postTiming{File: filePath, Duration: since(start)})
 	}
 
-	return nil
+	// Link the translations, once all the posts are known.
+	if err := pr.processTranslations(); err != nil {
+		return err
+	}
+
+	// Resolve the pinned related posts, once all the posts are known.
+	if err := pr.processRelated(); err != nil {
+		return err
+	}
+
+	// Check the routes, once all the posts are known.
+	return pr.processRoutes()
 }
 
 // processPost constructs a Post and optional Category
@@ -150,9 +199,26 @@ func (pr *processor) processPost(mkdownBytes []byte) error {
 	isPage := m.getBool("page", false)
 	isBlog := !isPage
 
+	// Parse lang from metadata ---------------------------
+	lang := pr.languages[0]
+
+	if code := m.getString("lang", ""); code != "" {
+		lang = pr.language(code)
+		if lang == nil {
+			return fmt.Errorf("could not load language [%s] [%s]", code, slug)
+		}
+	}
+
+	postsBySlug := pr.postsByLang[lang.Code]
+	categoriesBySlug := pr.categoriesByLang[lang.Code]
+
+	// Parse translation_key from metadata ----------------
+	translationKey := m.getString("translation_key", "")
+
 	// This check ensures that post slugs remain unique by guarding
-	// against slug collision.
-	if _, exists := pr.postsBySlug[slug]; exists {
+	// against slug collision. Translations can share a slug, since
+	// they are served under the language prefix.
+	if _, exists := postsBySlug[slug]; exists {
 		return fmt.Errorf("post collision [%s]\n", slug)
 	}
 
@@ -186,7 +252,7 @@ func (pr *processor) processPost(mkdownBytes []byte) error {
 
 		catSlug := utils.Slugify(catTitle)
 
-		existingCat, ok := pr.categoriesBySlug[catSlug]
+		existingCat, ok := categoriesBySlug[catSlug]
 		// Handle the case where the category exists.
 		if ok {
 			// If multiple categories differ in case (ex 'Gardening Tips' and 'GarDENing TIPS'),
@@ -206,9 +272,10 @@ func (pr *processor) processPost(mkdownBytes []byte) error {
 		if !ok {
 			cat.Title = catTitle
 			cat.Slug = catSlug
+			cat.route = lang.Prefix + pr.permalinks.categoryRoute(catSlug)
 
 			// Save the category.
-			pr.categoriesBySlug[catSlug] = cat
+			categoriesBySlug[catSlug] = cat
 		}
 	}
 
@@ -234,6 +301,32 @@ func (pr *processor) processPost(mkdownBytes []byte) error {
 		img.Height = imageHeight
 	}
 
+	// Parse aliases from metadata -----------------------
+	aliases, err := m.getStrings("aliases")
+	if err != nil {
+		return err
+	}
+
+	for i := range aliases {
+		aliases[i] = aliasRoute(aliases[i])
+	}
+
+	// Parse tags from metadata --------------------------
+	tags, err := m.getStrings("tags")
+	if err != nil {
+		return err
+	}
+
+	for i := range tags {
+		tags[i] = utils.Slugify(tags[i])
+	}
+
+	// Parse related from metadata -----------------------
+	related, err := m.getStrings("related")
+	if err != nil {
+		return err
+	}
+
 	// Render the markdown content to a buffer.
 	buf := new(bytes.Buffer)
 	if err := pr.markdown.Renderer().Render(buf, mkdownBytes, doc); err != nil {
@@ -241,7 +334,7 @@ func (pr *processor) processPost(mkdownBytes []byte) error {
 	}
 
 	// Save the post.
-	pr.postsBySlug[slug] = &post{
+	p := &post{
 		IsPage:      isPage,
 		IsBlog:      isBlog,
 		IsFeatured:  isFeatured,
@@ -257,11 +350,202 @@ func (pr *processor) processPost(mkdownBytes []byte) error {
 		PubdateTS:   pubdateTs,
 		Updated:     updated,
 		UpdatedTS:   updatedTs,
+		Aliases:     aliases,
+		Tags:        tags,
+		Related:     related,
+
+		Lang:           lang,
+		TranslationKey: translationKey,
+	}
+
+	p.route = lang.Prefix + pr.permalinks.postRoute(p)
+	postsBySlug[slug] = p
+
+	return nil
+}
+
+// processTranslations links the posts which share a translation key.
+// Each language can have one translation of a post.
+// .
+func (pr *processor) processTranslations() error {
+	postsByKey := make(map[string][]*post, 20)
+
+	// Collect the posts in the order of the languages.
+	for _, lang := range pr.languages {
+		postsBySlug := pr.postsByLang[lang.Code]
+
+		for _, slug := range sortedKeys(postsBySlug) {
+			p := postsBySlug[slug]
+			if p.TranslationKey == "" {
+				continue
+			}
+
+			for _, other := range postsByKey[p.TranslationKey] {
+				if other.Lang == p.Lang {
+					return fmt.Errorf("translation collision [%s] from [%s] and [%s]", p.TranslationKey, other.Slug, p.Slug)
+				}
+			}
+
+			postsByKey[p.TranslationKey] = append(postsByKey[p.TranslationKey], p)
+		}
+	}
+
+	for _, posts := range postsByKey {
+		for _, p := range posts {
+			for _, other := range posts {
+				if other != p {
+					p.Translations = append(p.Translations, other)
+				}
+			}
+		}
 	}
 
 	return nil
 }
 
+// processRelated resolves the pinned related posts from their slugs.
+// They must be blog posts in the same language.
+// .
+func (pr *processor) processRelated() error {
+	for _, lang := range pr.languages {
+		postsBySlug := pr.postsByLang[lang.Code]
+
+		for _, slug := range sortedKeys(postsBySlug) {
+			p := postsBySlug[slug]
+
+			for _, relatedSlug := range p.Related {
+				related, ok := postsBySlug[relatedSlug]
+				if !ok || !related.IsBlog || related == p {
+					return fmt.Errorf("could not load related post [%s] [%s]", relatedSlug, lang.label(slug))
+				}
+
+				p.relatedPinned = append(p.relatedPinned, related)
+			}
+		}
+	}
+
+	return nil
+}
+
+// processRoutes checks that the post, page and category routes are unique,
+// since permalink patterns can produce the same route for different posts.
+// Then, it collects the redirects from the post aliases and the config,
+// and checks that they do not collide with other routes.
+// .
+func (pr *processor) processRoutes() error {
+	// The owner of every known route, for error messages.
+	routes := make(map[string]string, 64)
+
+	for _, route := range reservedRoutes {
+		routes[route] = "built-in route"
+	}
+
+	// The homepage and sitemap of the other languages.
+	for _, lang := range pr.languages[1:] {
+		routes[lang.homeRoute()] = "built-in route"
+		routes[lang.Prefix+"/sitemap/"] = "built-in route"
+	}
+
+	// The xml sitemap of every language, for multilingual sites.
+	if len(pr.languages) > 1 {
+		for _, lang := range pr.languages {
+			routes[lang.sitemapRoute()] = "built-in route"
+		}
+	}
+
+	addRoute := func(route, owner string) error {
+		if existing, ok := routes[route]; ok {
+			return fmt.Errorf("route collision [%s] from %s and %s", route, owner, existing)
+		}
+
+		if strings.HasPrefix(route, "/media/") {
+			return fmt.Errorf("route collision [%s] from %s and the media files", route, owner)
+		}
+
+		for _, lang := range pr.languages {
+			if strings.HasPrefix(route, archiveRoute(lang.Prefix)) {
+				return fmt.Errorf("route collision [%s] from %s and the archive pages", route, owner)
+			}
+		}
+
+		routes[route] = owner
+		return nil
+	}
+
+	// Collect the routes, sorted so that the collision errors are deterministic.
+	for _, lang := range pr.languages {
+		categoriesBySlug := pr.categoriesByLang[lang.Code]
+
+		for _, slug := range sortedKeys(categoriesBySlug) {
+			if err := addRoute(categoriesBySlug[slug].Route(), fmt.Sprintf("category [%s]", lang.label(slug))); err != nil {
+				return err
+			}
+		}
+
+		postsBySlug := pr.postsByLang[lang.Code]
+
+		for _, slug := range sortedKeys(postsBySlug) {
+			if err := addRoute(postsBySlug[slug].Route(), fmt.Sprintf("post [%s]", lang.label(slug))); err != nil {
+				return err
+			}
+		}
+	}
+
+	addRedirect := func(from, to, owner string) error {
+		if err := addRoute(from, owner); err != nil {
+			return err
+		}
+
+		pr.redirectsByRoute[from] = redirect{From: from, To: to}
+		return nil
+	}
+
+	// Collect the post aliases.
+	for _, lang := range pr.languages {
+		postsBySlug := pr.postsByLang[lang.Code]
+
+		for _, slug := range sortedKeys(postsBySlug) {
+			p := postsBySlug[slug]
+
+			for _, alias := range p.Aliases {
+				if err := addRedirect(alias, p.Route(), fmt.Sprintf("post [%s]", lang.label(slug))); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// Collect the config redirects.
+	for _, from := range sortedKeys(pr.redirects) {
+		to := pr.redirects[from]
+
+		if to == "" {
+			return fmt.Errorf("redirect is missing a destination [%s]", from)
+		}
+
+		if !strings.Contains(to, "://") && !strings.HasPrefix(to, "/") {
+			to = "/" + to
+		}
+
+		if err := addRedirect(aliasRoute(from), to, "config redirects"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// language returns the site language for the given code, or nil.
+// .
+func (pr *processor) language(code string) *language {
+	for _, lang := range pr.languages {
+		if lang.Code == code {
+			return lang
+		}
+	}
+	return nil
+}
+
 // ------------------------------------------------------------------
 //
 //
@@ -295,6 +579,32 @@ func (m *metadataParser) getString(key string, defaultVal string) string {
 	return m.metadata[key].(string)
 }
 
+// getStrings retrieves and converts a metadata value into a slice of strings.
+// A single string is also accepted.
+// .
+func (m *metadataParser) getStrings(key string) ([]string, error) {
+	if !m.exists(key) {
+		return nil, nil
+	}
+
+	switch v := m.metadata[key].(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a list of strings [%s]", key, m.label)
+			}
+			values = append(values, str)
+		}
+		return values, nil
+	}
+
+	return nil, fmt.Errorf("%s must be a list of strings [%s]", key, m.label)
+}
+
 // mustGetString retrieves and converts a metadata value into a string.
 // If not found, then an error is returned.
 // .
@@ -0,0 +1,182 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gingersnap/app/utils"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Type: projectDirs
+//
+//
+// ------------------------------------------------------------------
+
+// The name of the project's config file.
+const configName = "gingersnap.json"
+
+// projectDirs stores the project directories from the config.
+// They are relative to the config file, so that the content
+// can live anywhere in a larger repository.
+//
+// ex:
+//
+//	"postsDir": "content/posts",
+//	"mediaDir": "content/media",
+//	"outputDir": "build/site",
+//	"templatesDir": "theme"
+//
+// .
+type projectDirs struct {
+	// The markdown posts (default "posts")
+	PostsDir string `json:"postsDir"`
+
+	// The media files (default "media")
+	MediaDir string `json:"mediaDir"`
+
+	// The exported site (default "dist")
+	OutputDir string `json:"outputDir"`
+
+	// The templates which override the built-in templates, by file name (optional)
+	TemplatesDir string `json:"templatesDir"`
+
+	// The deploy directory or git repository, see deployConfig (optional)
+	Repository string `json:"repository"`
+	Deploy     struct {
+		Target string `json:"target"`
+		Git    struct {
+			Repository string `json:"repository"`
+		} `json:"git"`
+		Dir struct {
+			Path string `json:"path"`
+		} `json:"dir"`
+	} `json:"deploy"`
+}
+
+// deployDir returns the path of the dir or git deploy target.
+// The legacy `repository` setting is used as the git target.
+// .
+func (d *projectDirs) deployDir() string {
+	switch d.Deploy.Target {
+	case "dir":
+		return d.Deploy.Dir.Path
+	case "", "git":
+		if d.Deploy.Git.Repository != "" {
+			return d.Deploy.Git.Repository
+		}
+		return d.Repository
+	}
+	return ""
+}
+
+// FindConfig searches for the config file in the directory and
+// its parents, like git does, and returns the path to it,
+// relative to the directory.
+//
+// ex: "gingersnap.json", "../../gingersnap.json"
+// .
+func FindConfig(dir string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for d := absDir; ; d = filepath.Dir(d) {
+		if utils.Exists(filepath.Join(d, configName)) {
+			rel, err := filepath.Rel(absDir, d)
+			if err != nil {
+				return "", err
+			}
+			return filepath.Join(dir, rel, configName), nil
+		}
+
+		// Stop at the filesystem root.
+		if filepath.Dir(d) == d {
+			return "", fmt.Errorf("could not find %s in %s or its parents", configName, absDir)
+		}
+	}
+}
+
+// ResolvePaths sets the empty project paths from the config's
+// directories, relative to the config file. If the config
+// file does not exist, then the default directories are used.
+// .
+func (g *Gingersnap) ResolvePaths() error {
+	dirs := projectDirs{
+		PostsDir:  "posts",
+		MediaDir:  "media",
+		OutputDir: "dist",
+	}
+
+	if utils.Exists(g.ConfigPath) {
+		configBytes, err := utils.ReadFile(g.ConfigPath)
+		if err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal(configBytes, &dirs); err != nil {
+			return fmt.Errorf("could not load config [%s]: %w", g.ConfigPath, err)
+		}
+	}
+
+	root := filepath.Dir(g.ConfigPath)
+
+	resolve := func(p *string, dir string) {
+		if *p != "" || dir == "" {
+			return
+		}
+		if filepath.IsAbs(dir) {
+			*p = dir
+			return
+		}
+		*p = filepath.Join(root, dir)
+	}
+
+	resolve(&g.PostsPath, dirs.PostsDir)
+	resolve(&g.MediaPath, dirs.MediaDir)
+	resolve(&g.ExportPath, dirs.OutputDir)
+	resolve(&g.TemplatesPath, dirs.TemplatesDir)
+	resolve(&g.MessagesPath, "i18n")
+	resolve(&g.ArchetypesPath, "archetypes")
+	resolve(&g.DeployPath, dirs.deployDir())
+
+	return nil
+}
+
+// Unpack copies the starter config, posts and media into the project paths.
+// This is used when initializing a new gingersnap project.
+// .
+func (g *Gingersnap) Unpack() error {
+	dirs := map[string]string{
+		"assets/media": g.MediaPath,
+		"assets/posts": g.PostsPath,
+	}
+
+	for _, src := range sortedKeys(dirs) {
+		sub, err := fs.Sub(assets, src)
+		if err != nil {
+			return err
+		}
+
+		if err := utils.CopyDir(sub, ".", dirs[src]); err != nil {
+			return err
+		}
+	}
+
+	return utils.CopyFile(assets, "assets/config/gingersnap.json", g.ConfigPath)
+}
+
+// projectTemplates returns the project's template overrides, or nil.
+// .
+func (g *Gingersnap) projectTemplates() fs.FS {
+	if g.TemplatesPath == "" {
+		return nil
+	}
+	return os.DirFS(g.TemplatesPath)
+}
@@ -0,0 +1,26 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedirectPage(t *testing.T) {
+	to := `https://example.com/search?q=go&lang="en"`
+	g := newTestSite(t, map[string]any{"redirects": map[string]string{"/old/": to}})
+
+	w := httptest.NewRecorder()
+	g.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/old/", nil))
+
+	if w.Code != http.StatusMovedPermanently {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusMovedPermanently)
+	}
+
+	// The url is escaped in the link, and in its text.
+	want := `<a href="https://example.com/search?q=go&amp;lang=%22en%22">https://example.com/search?q=go&amp;lang=&#34;en&#34;</a>`
+	if body := w.Body.String(); !strings.Contains(body, want) {
+		t.Errorf("got body\n%s\nwant the link %s", body, want)
+	}
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Redirect files
+//
+//
+// ------------------------------------------------------------------
+
+// redirectFormat is a redirect file, written alongside the exported site
+// for hosts which support server-side redirects.
+// .
+type redirectFormat struct {
+	// The file name, relative to the export directory
+	fileName string
+
+	// Builds the file from the redirects, under the base path
+	build func(redirects []redirect, basePath string) []byte
+}
+
+// redirectFormats lists the supported redirect files, by name.
+var redirectFormats = map[string]redirectFormat{
+	"netlify": {fileName: "_redirects", build: netlifyRedirects},
+	"nginx":   {fileName: "redirects.map", build: nginxRedirects},
+}
+
+// netlifyRedirects builds a `_redirects` file, which
+// is supported by Netlify and Cloudflare Pages.
+//
+// ex: /old-slug/ /new-slug/ 301
+// .
+func netlifyRedirects(redirects []redirect, basePath string) []byte {
+	buf := new(bytes.Buffer)
+
+	for _, rd := range redirects {
+		fmt.Fprintf(buf, "%s %s 301\n", basePath+rd.From, prefixUrl(rd.To, basePath))
+	}
+
+	return buf.Bytes()
+}
+
+// nginxRedirects builds an nginx map of old paths to new urls.
+// .
+func nginxRedirects(redirects []redirect, basePath string) []byte {
+	buf := new(bytes.Buffer)
+
+	buf.WriteString("# Include this file in the http block, and redirect in the server block:\n")
+	buf.WriteString("#\n")
+	buf.WriteString("#   if ($redirect_uri) {\n")
+	buf.WriteString("#       return 301 $redirect_uri;\n")
+	buf.WriteString("#   }\n")
+	buf.WriteString("#\n")
+	buf.WriteString("map $uri $redirect_uri {\n")
+
+	for _, rd := range redirects {
+		fmt.Fprintf(buf, "    %q %q;\n", basePath+rd.From, prefixUrl(rd.To, basePath))
+	}
+
+	buf.WriteString("}\n")
+
+	return buf.Bytes()
+}
@@ -0,0 +1,315 @@
+package app
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Type: relatedConfig
+//
+//
+// ------------------------------------------------------------------
+
+// relatedConfig stores the related posts settings.
+//
+// ex:
+//
+//	"related": {"count": 4}
+//
+// .
+type relatedConfig struct {
+	// The number of related posts for each post (default 6).
+	// Zero disables the related posts.
+	Count int `json:"count"`
+}
+
+// The weights of the related posts score.
+//
+// The text similarity ranges from 0 to 1, and is the main
+// signal. The category, tags and recency break the ties
+// between posts with similar text.
+const relatedWeightText = 1.0
+const relatedWeightCategory = 0.3
+const relatedWeightTags = 0.2
+const relatedWeightRecency = 0.1
+
+// The weights of the post fields in the text similarity.
+const relatedWeightTitle = 3
+const relatedWeightDescription = 2
+const relatedWeightBody = 1
+
+// htmlTagPattern matches the HTML tags of a rendered post body.
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// ------------------------------------------------------------------
+//
+//
+// Type: relatedIndex
+//
+//
+// ------------------------------------------------------------------
+
+// relatedIndex ranks the related posts of every blog post.
+//
+// Each post is scored against the other posts by the TF-IDF cosine
+// similarity of their titles, descriptions and bodies, plus bonuses
+// for a shared category, shared tags and recency. The posts pinned
+// with the `related` front matter always come first.
+//
+// Main method: `rank()`
+// .
+type relatedIndex struct {
+	posts []*post
+
+	// The TF-IDF vector of each post, normalized to a unit length
+	vectors map[*post]termVector
+
+	// The oldest and newest pubdates, for the recency bonus
+	oldestTS int
+	newestTS int
+}
+
+// termVector is a sparse vector, sorted by term id.
+// .
+type termVector []termWeight
+
+type termWeight struct {
+	term   int
+	weight float64
+}
+
+// newRelatedIndex builds the TF-IDF vectors for the posts.
+// .
+func newRelatedIndex(posts []*post) *relatedIndex {
+	idx := &relatedIndex{
+		posts:   posts,
+		vectors: make(map[*post]termVector, len(posts)),
+	}
+
+	// [1/3] Count the weighted terms of each post --------
+
+	counts := make(map[*post]map[string]float64, len(posts))
+	docFreq := make(map[string]int, 1024)
+
+	for _, p := range posts {
+		c := make(map[string]float64, 256)
+
+		addTerms(c, p.Title, relatedWeightTitle)
+		addTerms(c, p.Description, relatedWeightDescription)
+		addTerms(c, htmlTagPattern.ReplaceAllString(p.Body, " "), relatedWeightBody)
+
+		for term := range c {
+			docFreq[term]++
+		}
+		counts[p] = c
+
+		if idx.oldestTS == 0 || p.PubdateTS < idx.oldestTS {
+			idx.oldestTS = p.PubdateTS
+		}
+		if p.PubdateTS > idx.newestTS {
+			idx.newestTS = p.PubdateTS
+		}
+	}
+
+	// [2/3] Assign the term ids, in sorted order ---------
+
+	// Sorted ids make the vectors, and so the floating
+	// point sums, identical across builds.
+	terms := sortedKeys(docFreq)
+	termIds := make(map[string]int, len(terms))
+
+	for i, term := range terms {
+		termIds[term] = i
+	}
+
+	// [3/3] Build the normalized TF-IDF vectors ----------
+
+	n := float64(len(posts))
+
+	for _, p := range posts {
+		v := make(termVector, 0, len(counts[p]))
+
+		for term, count := range counts[p] {
+			// Terms which appear in every post carry no signal.
+			weight := count * math.Log(n/float64(docFreq[term]))
+			if weight == 0 {
+				continue
+			}
+
+			v = append(v, termWeight{term: termIds[term], weight: weight})
+		}
+
+		sort.Slice(v, func(i, j int) bool { return v[i].term < v[j].term })
+
+		norm := 0.0
+		for _, tw := range v {
+			norm += tw.weight * tw.weight
+		}
+
+		norm = math.Sqrt(norm)
+		for i := 0; norm > 0 && i < len(v); i++ {
+			v[i].weight /= norm
+		}
+
+		idx.vectors[p] = v
+	}
+
+	return idx
+}
+
+// addTerms adds the lowercase words of the text to the counts,
+// with the given weight. Single-character words are skipped.
+// .
+func addTerms(counts map[string]float64, text string, weight float64) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	for _, word := range words {
+		if len([]rune(word)) > 1 {
+			counts[word] += weight
+		}
+	}
+}
+
+// rank returns up to `count` related posts for the post, highest score
+// first. Ties are broken by pubdate, then by slug, so that the result
+// is deterministic. Unrelated posts are never returned.
+// .
+func (idx *relatedIndex) rank(p *post, count int) []*post {
+	related := make([]*post, 0, count)
+	seen := map[*post]bool{p: true}
+
+	// The pinned posts come first.
+	for _, pinned := range p.relatedPinned {
+		if len(related) < count && !seen[pinned] {
+			related = append(related, pinned)
+			seen[pinned] = true
+		}
+	}
+
+	type candidate struct {
+		post  *post
+		score float64
+	}
+
+	candidates := make([]candidate, 0, len(idx.posts))
+
+	for _, other := range idx.posts {
+		if seen[other] {
+			continue
+		}
+
+		if score := idx.score(p, other); score > 0 {
+			candidates = append(candidates, candidate{post: other, score: score})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if a.post.PubdateTS != b.post.PubdateTS {
+			return a.post.PubdateTS > b.post.PubdateTS
+		}
+		return a.post.Slug < b.post.Slug
+	})
+
+	for _, c := range candidates {
+		if len(related) == count {
+			break
+		}
+		related = append(related, c.post)
+	}
+
+	return related
+}
+
+// score returns the related score of the other post. It is zero if
+// the posts share no text, category or tags, so that the recency
+// alone does not make a post related.
+// .
+func (idx *relatedIndex) score(p, other *post) float64 {
+	text := idx.vectors[p].dot(idx.vectors[other])
+
+	category := 0.0
+	if !p.Category.IsEmpty() && p.Category == other.Category {
+		category = 1
+	}
+
+	tags := tagSimilarity(p.Tags, other.Tags)
+
+	if text == 0 && category == 0 && tags == 0 {
+		return 0
+	}
+
+	recency := 1.0
+	if span := idx.newestTS - idx.oldestTS; span > 0 {
+		recency = float64(other.PubdateTS-idx.oldestTS) / float64(span)
+	}
+
+	return relatedWeightText*text +
+		relatedWeightCategory*category +
+		relatedWeightTags*tags +
+		relatedWeightRecency*recency
+}
+
+// dot returns the dot product of two vectors, which is
+// their cosine similarity, since they are normalized.
+// .
+func (v termVector) dot(other termVector) float64 {
+	sum := 0.0
+
+	for i, j := 0, 0; i < len(v) && j < len(other); {
+		switch {
+		case v[i].term < other[j].term:
+			i++
+		case v[i].term > other[j].term:
+			j++
+		default:
+			sum += v[i].weight * other[j].weight
+			i++
+			j++
+		}
+	}
+
+	return sum
+}
+
+// tagSimilarity returns the Jaccard similarity of two tag lists,
+// from 0 (no shared tags) to 1 (the same tags).
+// .
+func tagSimilarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	set := make(map[string]bool, len(a))
+	for _, tag := range a {
+		set[tag] = true
+	}
+
+	shared := 0
+	union := len(set)
+
+	for _, tag := range b {
+		inA, ok := set[tag]
+		switch {
+		case inA:
+			shared++
+			set[tag] = false
+		case !ok:
+			union++
+			set[tag] = false
+		}
+	}
+
+	return float64(shared) / float64(union)
+}
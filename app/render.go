@@ -40,6 +40,10 @@ type renderData struct {
 	// The copyright year
 	Copyright string
 
+	// The page language, and the hreflang links to its translations
+	Lang       *language
+	Alternates []alternate
+
 	// Post data
 	Post          *post
 	Posts         []*post
@@ -47,17 +51,28 @@ type renderData struct {
 	RelatedPosts  []*post
 	FeaturedPosts []*post
 
+	// The destination of a redirect page
+	RedirectUrl string
+
 	// Category data
 	Category   category
 	Categories []category
 
+	// The archive years, by year and month
+	Archive []archiveYear
+
 	// Layout and styling
 	Sections    []section
 	NavbarLinks []siteLink
 	FooterLinks []siteLink
 	Theme       theme
+	Themes      []themePreview
 	Display     display
 
+	// The fingerprinted urls of the stylesheets
+	StylesUrl    string
+	HighlightUrl string
+
 	// Metrics
 	AnalyticsTag string
 
@@ -67,7 +82,7 @@ type renderData struct {
 	AppTrace string
 }
 
-func (g *Gingersnap) newRenderData(r *http.Request) renderData {
+func (g *Gingersnap) newRenderData(r *http.Request, lang *language) renderData {
 	pageUrl := ""
 	if r != nil {
 		pageUrl = fmt.Sprintf("%s%s", g.config.Site.Url, r.URL.RequestURI())
@@ -77,24 +92,38 @@ func (g *Gingersnap) newRenderData(r *http.Request) renderData {
 		SiteHost:    g.config.Site.Host,
 		SiteUrl:     g.config.Site.Url,
 		SiteName:    g.config.Site.Name,
-		SiteTagline: g.config.Site.Tagline,
+		SiteTagline: lang.Tagline,
 		SiteEmail:   g.config.Site.Email,
 		PageUrl:     pageUrl,
 
 		Image: g.config.Site.Image,
 
-		Title:       g.config.Site.Title,
-		Description: g.config.Site.Description,
-		Heading:     g.config.Site.Tagline,
+		Title:       lang.Title,
+		Description: lang.Description,
+		Heading:     lang.Tagline,
+
+		Lang: lang,
 
-		NavbarLinks: g.config.NavbarLinks,
-		FooterLinks: g.config.FooterLinks,
+		NavbarLinks: lang.NavbarLinks,
+		FooterLinks: lang.FooterLinks,
 		Theme:       g.config.Theme,
 		Display:     g.config.Site.Display,
 
+		StylesUrl:    g.assetUrls["/styles.css"],
+		HighlightUrl: g.assetUrls["/highlight.css"],
+
 		AnalyticsTag: g.config.AnalyticsTag,
 
 		Copyright: fmt.Sprintf("%d", time.Now().Year()),
 		AppDebug:  g.config.Debug,
 	}
 }
+
+// T returns the translated UI string for the key, in the page language.
+// Format strings are formatted with the args.
+//
+// ex: {{.T "total_posts" (len .Posts)}}
+// .
+func (rd *renderData) T(key string, args ...any) string {
+	return rd.Lang.messages.translate(key, args...)
+}
@@ -0,0 +1,207 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	textTmp "text/template"
+	"time"
+	"unicode"
+
+	"gingersnap/app/utils"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Type: archetype
+//
+//
+// ------------------------------------------------------------------
+
+// The kinds of content which can be scaffolded.
+const contentPost = "post"
+const contentPage = "page"
+
+// archetype stores the values for a content template.
+//
+// The built-in templates can be overridden by the project's
+// `archetypes/post.md` and `archetypes/page.md` files.
+//
+// ex:
+//
+//	---
+//	title: {{quote .Title}}
+//	slug: {{.Slug}}
+//	category: {{quote .Category}}
+//	pubdate: {{.Pubdate}}
+//	---
+//
+// .
+type archetype struct {
+	// The title, as given
+	Title string
+
+	// The slug, built from the title
+	Slug string
+
+	// The category title (empty for pages)
+	Category string
+
+	// The lead image url (the site image)
+	Image string
+
+	// Today's date, ex: "2023-06-21"
+	Pubdate string
+}
+
+// NewContent creates a markdown file for a new post or page, and returns its path.
+//
+// The category must exist, see `contentCategory`. The engine must be
+// configured, so that the existing posts and categories are known.
+// .
+func (g *Gingersnap) NewContent(kind, title, categoryTitle string) (string, error) {
+	if kind != contentPost && kind != contentPage {
+		return "", fmt.Errorf("could not create content [%s]: kind must be %q or %q", kind, contentPost, contentPage)
+	}
+
+	slug := titleSlug(title)
+	if slug == "" {
+		return "", fmt.Errorf("could not create %s [%s]: missing title", kind, title)
+	}
+
+	// This check ensures that post slugs remain unique.
+	if _, exists := g.store.postsBySlug[slug]; exists {
+		return "", fmt.Errorf("post collision [%s]", slug)
+	}
+
+	filePath := filepath.Join(g.PostsPath, slug+".md")
+	if utils.Exists(filePath) {
+		return "", fmt.Errorf("could not create %s [%s]: %s already exists", kind, slug, filePath)
+	}
+
+	a := archetype{
+		Title:   strings.TrimSpace(title),
+		Slug:    slug,
+		Image:   g.config.Site.Image.Url,
+		Pubdate: time.Now().Format(time.DateOnly),
+	}
+
+	if kind == contentPost {
+		cat, err := g.store.contentCategory(categoryTitle)
+		if err != nil {
+			return "", err
+		}
+		a.Category = cat.Title
+	}
+
+	data, err := g.renderArchetype(kind, a)
+	if err != nil {
+		return "", err
+	}
+
+	if err := utils.WriteFile(filePath, data); err != nil {
+		return "", err
+	}
+
+	return filePath, nil
+}
+
+// renderArchetype renders the content template for the kind. The project's
+// template `<ArchetypesPath>/<kind>.md` is used if it exists.
+// .
+func (g *Gingersnap) renderArchetype(kind string, a archetype) ([]byte, error) {
+	name := kind + ".md"
+
+	var tmplBytes []byte
+	var err error
+
+	if filePath := filepath.Join(g.ArchetypesPath, name); utils.Exists(filePath) {
+		tmplBytes, err = utils.ReadFile(filePath)
+	} else {
+		tmplBytes, err = assets.ReadFile("assets/archetypes/" + name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not load archetype [%s]: %w", name, err)
+	}
+
+	funcs := textTmp.FuncMap{"quote": strconv.Quote}
+
+	tmpl, err := textTmp.New(name).Funcs(funcs).Parse(string(tmplBytes))
+	if err != nil {
+		return nil, fmt.Errorf("could not load archetype [%s]: %w", name, err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, a); err != nil {
+		return nil, fmt.Errorf("could not render archetype [%s]: %w", name, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// contentCategory returns the existing category with the given title.
+// If the title is empty, then the category with the most posts is returned.
+// If the site has no categories, then the title starts a new category.
+// .
+func (s *store) contentCategory(title string) (category, error) {
+	if title != "" && len(s.categories) == 0 {
+		return category{Title: title, Slug: utils.Slugify(title)}, nil
+	}
+
+	if title != "" {
+		cat, ok := s.categoriesBySlug[utils.Slugify(title)]
+		if !ok {
+			return category{}, fmt.Errorf("could not load category [%s]: use one of [%s]", title, strings.Join(s.categoryTitles(), ", "))
+		}
+		return cat, nil
+	}
+
+	if len(s.categories) == 0 {
+		return category{}, fmt.Errorf("could not load category: the site has no categories yet, so a category is required")
+	}
+
+	cats := make([]category, len(s.categories))
+	copy(cats, s.categories)
+
+	// Sort by post count, then by title, so that the choice is stable.
+	sort.Slice(cats, func(i, j int) bool {
+		a, b := len(s.postsByCategory[cats[i]]), len(s.postsByCategory[cats[j]])
+		if a != b {
+			return a > b
+		}
+		return cats[i].Title < cats[j].Title
+	})
+
+	return cats[0], nil
+}
+
+// categoryTitles returns the sorted category titles.
+// .
+func (s *store) categoryTitles() []string {
+	titles := make([]string, 0, len(s.categories))
+
+	for _, cat := range s.categories {
+		titles = append(titles, cat.Title)
+	}
+
+	sort.Strings(titles)
+
+	return titles
+}
+
+// titleSlug builds a url-safe slug from a title. Letters and numbers
+// are kept, and every other run of characters becomes a dash.
+//
+// ex: "Hello, World! (Part 2)"  =>  "hello-world-part-2"
+// .
+func titleSlug(title string) string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	return strings.Join(words, "-")
+}
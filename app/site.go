@@ -0,0 +1,288 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"gingersnap/app/utils"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Type: Options
+//
+//
+// ------------------------------------------------------------------
+
+// Options configures a gingersnap engine, which is embedded in a Go program.
+// The empty paths are set from the config's directories.
+//
+// ex:
+//
+//	g, err := app.New(app.Options{ConfigPath: "blog/gingersnap.json"})
+//
+// .
+type Options struct {
+	// The config file, ex: "blog/gingersnap.json" (required)
+	ConfigPath string
+
+	// The content directories, ex: "blog/posts"
+	PostsPath string
+	MediaPath string
+
+	// The template overrides, translated UI strings and content templates
+	TemplatesPath  string
+	MessagesPath   string
+	ArchetypesPath string
+
+	// Debug disables the caching, and shows the stack traces of server errors
+	Debug bool
+
+	// The logger (default is a text logger on stderr)
+	Logger *slog.Logger
+}
+
+// New returns a gingersnap engine for the options. The project
+// is not read until the site is built, ex: `g.Build(ctx)`.
+// .
+func New(opts Options) (*Gingersnap, error) {
+	if opts.ConfigPath == "" {
+		return nil, errors.New("could not load config: the config path is empty")
+	}
+
+	if !utils.Exists(opts.ConfigPath) {
+		return nil, fmt.Errorf("could not load config [%s]: no such file", opts.ConfigPath)
+	}
+
+	g := &Gingersnap{
+		Debug:          opts.Debug,
+		ConfigPath:     opts.ConfigPath,
+		PostsPath:      opts.PostsPath,
+		MediaPath:      opts.MediaPath,
+		TemplatesPath:  opts.TemplatesPath,
+		MessagesPath:   opts.MessagesPath,
+		ArchetypesPath: opts.ArchetypesPath,
+		Logger:         opts.Logger,
+	}
+
+	if err := g.ResolvePaths(); err != nil {
+		return nil, err
+	}
+
+	if !utils.Exists(g.PostsPath) {
+		return nil, fmt.Errorf("could not load posts [%s]: no such directory", g.PostsPath)
+	}
+
+	return g, nil
+}
+
+// Build reads the project, and returns the built site. Each build
+// returns a new site, so a site is not changed by a later build.
+// .
+func (g *Gingersnap) Build(ctx context.Context) (*Site, error) {
+	e := &Gingersnap{
+		Debug:          g.Debug,
+		ConfigPath:     g.ConfigPath,
+		PostsPath:      g.PostsPath,
+		MediaPath:      g.MediaPath,
+		ExportPath:     g.ExportPath,
+		DeployPath:     g.DeployPath,
+		ListenAddr:     g.ListenAddr,
+		Logger:         g.Logger,
+		MessagesPath:   g.MessagesPath,
+		ArchetypesPath: g.ArchetypesPath,
+		TemplatesPath:  g.TemplatesPath,
+	}
+
+	if err := e.configure(ctx); err != nil {
+		return nil, err
+	}
+
+	return &Site{g: e}, nil
+}
+
+// ------------------------------------------------------------------
+//
+//
+// Type: Site
+//
+//
+// ------------------------------------------------------------------
+
+// Site is a built gingersnap site. It gives read-only access to the
+// content, and serves or exports the site.
+//
+// ex:
+//
+//	site, err := g.Build(ctx)
+//	mux.Handle("/blog/", site.Handler())
+//
+// .
+type Site struct {
+	g *Gingersnap
+}
+
+// Post is a read-only copy of a blog post or a page.
+// .
+type Post struct {
+	Slug        string
+	Title       string
+	Heading     string
+	Description string
+	Category    Category
+	Tags        []string
+
+	// The lead image url, ex: "/media/some-image.webp"
+	Image string
+
+	// The url path, without the base path, ex: "/2022/10/some-slug/"
+	Route string
+
+	// The language code, ex: "en"
+	Lang string
+
+	// The rendered html body
+	Body string
+
+	// The publish and updated dates (zero if not set)
+	Pubdate time.Time
+	Updated time.Time
+
+	IsFeatured bool
+}
+
+// Category is a read-only copy of a post category.
+// .
+type Category struct {
+	Slug  string
+	Title string
+
+	// The url path, without the base path, ex: "/category/some-slug/"
+	Route string
+
+	// The language code, ex: "en"
+	Lang string
+}
+
+// Posts returns the blog posts of every language, newest first.
+// .
+func (s *Site) Posts() []Post {
+	posts := make([]Post, 0, s.g.stats.Posts)
+
+	for _, lang := range s.g.config.Languages {
+		for _, p := range s.g.stores[lang.Code].posts {
+			posts = append(posts, newPost(p))
+		}
+	}
+
+	return posts
+}
+
+// Pages returns the standalone posts (pages) of every language, by slug.
+// .
+func (s *Site) Pages() []Post {
+	pages := make([]Post, 0, s.g.stats.Pages)
+
+	for _, lang := range s.g.config.Languages {
+		start := len(pages)
+
+		for _, p := range s.g.stores[lang.Code].pages {
+			pages = append(pages, newPost(p))
+		}
+
+		sortBySlug(pages[start:], func(p Post) string { return p.Slug })
+	}
+
+	return pages
+}
+
+// Categories returns the categories of every language, by slug.
+// .
+func (s *Site) Categories() []Category {
+	cats := make([]Category, 0, s.g.stats.Categories)
+
+	for _, lang := range s.g.config.Languages {
+		start := len(cats)
+
+		for _, c := range s.g.stores[lang.Code].categories {
+			cats = append(cats, newCategory(c, lang))
+		}
+
+		sortBySlug(cats[start:], func(c Category) string { return c.Slug })
+	}
+
+	return cats
+}
+
+// Handler returns the http.Handler which serves the site, with the
+// same routes and middleware as the dev server. The site is served
+// under the config's base path, so mount it at the base path.
+// .
+func (s *Site) Handler() http.Handler {
+	return s.g.routes()
+}
+
+// Export exports the site as a static site, into the directory.
+// .
+func (s *Site) Export(ctx context.Context, dir string) error {
+	return s.g.export(ctx, dir)
+}
+
+// newPost returns a read-only copy of the post.
+// .
+func newPost(p *post) Post {
+	return Post{
+		Slug:        p.Slug,
+		Title:       p.Title,
+		Heading:     p.Heading,
+		Description: p.Description,
+		Category:    newCategory(p.Category, p.Lang),
+		Tags:        append([]string(nil), p.Tags...),
+		Image:       p.Image.Url,
+		Route:       p.Route(),
+		Lang:        p.Lang.Code,
+		Body:        p.Body,
+		Pubdate:     unixTime(p.PubdateTS),
+		Updated:     unixTime(p.UpdatedTS),
+		IsFeatured:  p.IsFeatured,
+	}
+}
+
+// newCategory returns a read-only copy of the category.
+// A post without a category has an empty category.
+// .
+func newCategory(c category, lang *language) Category {
+	if c.IsEmpty() {
+		return Category{}
+	}
+
+	return Category{
+		Slug:  c.Slug,
+		Title: c.Title,
+		Route: c.Route(),
+		Lang:  lang.Code,
+	}
+}
+
+// unixTime returns the UTC time of the timestamp, or the zero time.
+// .
+func unixTime(ts int) time.Time {
+	if ts == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(ts), 0).UTC()
+}
+
+// sortBySlug sorts the items by their slug.
+// .
+func sortBySlug[T any](items []T, slug func(T) string) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return slug(items[i]) < slug(items[j])
+	})
+}
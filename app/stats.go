@@ -0,0 +1,223 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"gingersnap/app/utils"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Type: buildStats
+//
+//
+// ------------------------------------------------------------------
+
+// The route types, for the output sizes in the stats.
+const routeHome = "home"
+const routePost = "post"
+const routePage = "page"
+const routeCategory = "category"
+const routeArchive = "archive"
+const routeSitemap = "sitemap"
+const routeRedirect = "redirect"
+const routeMedia = "media"
+const routeAsset = "asset"
+const routeOther = "other"
+
+// The number of slowest posts and routes in the stats table.
+const limitStats = 10
+
+// buildStats stores the timings and sizes of a build. The build steps are
+// timed by `Configure`, and the rendered routes are timed by the export.
+//
+// ex:
+//
+//	gingersnap export --stats
+//
+// .
+type buildStats struct {
+	// The content counts, for all languages
+	Posts      int `json:"posts"`
+	Pages      int `json:"pages"`
+	Categories int `json:"categories"`
+
+	// The build steps
+	Process   millis `json:"processMs"`
+	Stores    millis `json:"storesMs"`
+	Templates millis `json:"templatesMs"`
+	Render    millis `json:"renderMs"`
+	Media     millis `json:"mediaMs"`
+
+	// The processed post files, slowest first
+	PostTimes []postTiming `json:"postTimes"`
+
+	// The exported routes, slowest first
+	Routes []routeStat `json:"routes"`
+
+	// The exported bytes, by route type
+	Output map[string]*outputStat `json:"output"`
+}
+
+// postTiming is the time spent processing a markdown file.
+// .
+type postTiming struct {
+	File     string `json:"file"`
+	Duration millis `json:"durationMs"`
+}
+
+// routeStat is the time spent rendering a route, and its exported size.
+// .
+type routeStat struct {
+	Url      string `json:"url"`
+	Type     string `json:"type"`
+	Size     int    `json:"size"`
+	Duration millis `json:"durationMs"`
+}
+
+// outputStat is the number of routes and exported bytes for a route type.
+// .
+type outputStat struct {
+	Routes int `json:"routes"`
+	Size   int `json:"size"`
+}
+
+// millis is a duration, which is written as milliseconds in json.
+// .
+type millis time.Duration
+
+func (m millis) MarshalJSON() ([]byte, error) {
+	return json.Marshal(float64(m) / float64(time.Millisecond))
+}
+
+func (m millis) String() string {
+	return time.Duration(m).Round(10 * time.Microsecond).String()
+}
+
+// since returns the time elapsed since the start.
+// .
+func since(start time.Time) millis {
+	return millis(time.Since(start))
+}
+
+// sortTimings sorts the post timings, slowest first.
+// .
+func (st *buildStats) sortTimings() {
+	sort.SliceStable(st.PostTimes, func(i, j int) bool {
+		return st.PostTimes[i].Duration > st.PostTimes[j].Duration
+	})
+}
+
+// resetRoutes removes the routes of a previous export.
+// .
+func (st *buildStats) resetRoutes() {
+	st.Render = 0
+	st.Media = 0
+	st.Routes = nil
+	st.Output = nil
+}
+
+// addRoute records an exported route.
+// .
+func (st *buildStats) addRoute(r routeStat) {
+	st.Routes = append(st.Routes, r)
+
+	if r.Type == routeMedia {
+		st.Media += r.Duration
+	} else {
+		st.Render += r.Duration
+	}
+
+	if st.Output == nil {
+		st.Output = make(map[string]*outputStat, 10)
+	}
+
+	out, ok := st.Output[r.Type]
+	if !ok {
+		out = &outputStat{}
+		st.Output[r.Type] = out
+	}
+
+	out.Routes++
+	out.Size += r.Size
+}
+
+// sortRoutes sorts the exported routes, slowest first.
+// .
+func (st *buildStats) sortRoutes() {
+	sort.SliceStable(st.Routes, func(i, j int) bool {
+		return st.Routes[i].Duration > st.Routes[j].Duration
+	})
+}
+
+// WriteStats writes the stats of the last build, as a "table" or as "json".
+// The export stats are only included after an export.
+// .
+func (g *Gingersnap) WriteStats(w io.Writer, format string) error {
+	st := g.stats
+
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(st, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+
+	case "table":
+		st.writeTable(w)
+		return nil
+	}
+
+	return fmt.Errorf("could not load stats format [%s]", format)
+}
+
+// writeTable writes the build steps, the slowest posts and
+// routes, and the output sizes, as aligned tables.
+// .
+func (st *buildStats) writeTable(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "\nContent\t%d posts, %d pages, %d categories\t\n", st.Posts, st.Pages, st.Categories)
+
+	fmt.Fprintln(tw, "\nStep\tDuration\t")
+	fmt.Fprintf(tw, "process posts\t%s\t\n", st.Process)
+	fmt.Fprintf(tw, "init stores\t%s\t\n", st.Stores)
+	fmt.Fprintf(tw, "parse templates\t%s\t\n", st.Templates)
+
+	if len(st.Routes) > 0 {
+		fmt.Fprintf(tw, "render routes\t%s\t\n", st.Render)
+		fmt.Fprintf(tw, "copy media\t%s\t\n", st.Media)
+	}
+
+	fmt.Fprintln(tw, "\nSlowest posts\tDuration\t")
+	for _, t := range st.PostTimes[:min(limitStats, len(st.PostTimes))] {
+		fmt.Fprintf(tw, "%s\t%s\t\n", t.File, t.Duration)
+	}
+	tw.Flush()
+
+	if len(st.Routes) == 0 {
+		return
+	}
+
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "\nSlowest routes\tType\tSize\tDuration\t")
+	for _, r := range st.Routes[:min(limitStats, len(st.Routes))] {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t\n", r.Url, r.Type, utils.FormatBytes(r.Size), r.Duration)
+	}
+
+	fmt.Fprintln(tw, "\nOutput\tRoutes\tSize\t")
+	for _, routeType := range sortedKeys(st.Output) {
+		out := st.Output[routeType]
+		fmt.Fprintf(tw, "%s\t%d\t%s\t\n", routeType, out.Routes, utils.FormatBytes(out.Size))
+	}
+	tw.Flush()
+}
@@ -1,6 +1,11 @@
 package app
 
-import "sort"
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
 
 // Cutoff values for different post lists.
 const limitFeatured = 3
@@ -33,6 +38,13 @@ type store struct {
 	categoriesBySlug map[string]category
 
 	sections map[string]section
+	homepage []section
+
+	related map[*post][]*post
+
+	archive []archiveYear
+
+	redirects []redirect
 }
 
 func newStore() *store {
@@ -89,9 +101,6 @@ func (s *store) InitPosts(postsBySlug map[string]*post) {
 
 		// Add post to the category slice.
 		s.postsByCategory[cat] = append(s.postsByCategory[cat], p)
-
-		// Update the index of the post, relative to the category it is in.
-		p.idxCategory = len(s.postsByCategory[cat]) - 1
 	}
 
 	// [4/5] Prepare the latest posts.
@@ -122,14 +131,60 @@ func (s *store) InitCategories(categoriesBySlug map[string]category) {
 	}
 }
 
-func (s *store) InitSections() {
-	s.sections = make(map[string]section, len(s.postsByCategory)+2)
+func (s *store) InitRedirects(redirectsByRoute map[string]redirect) {
+	s.redirects = make([]redirect, 0, len(redirectsByRoute))
+
+	for _, route := range sortedKeys(redirectsByRoute) {
+		s.redirects = append(s.redirects, redirectsByRoute[route])
+	}
+}
+
+// InitArchive groups the blog posts by year and month, newest first.
+// The routes are prefixed with the language prefix.
+// .
+func (s *store) InitArchive(prefix string, m messages) {
+	s.archive = make([]archiveYear, 0, 8)
+
+	// The posts are already sorted by pubdate, newest first.
+	for _, p := range s.posts {
+		date := time.Unix(int64(p.PubdateTS), 0).UTC()
+		year, month := date.Year(), int(date.Month())
+
+		// Add the year, if it is a new one.
+		if n := len(s.archive); n == 0 || s.archive[n-1].Year != year {
+			s.archive = append(s.archive, archiveYear{
+				Year:  year,
+				route: fmt.Sprintf("%s%d/", archiveRoute(prefix), year),
+			})
+		}
+
+		y := &s.archive[len(s.archive)-1]
+		y.Posts = append(y.Posts, p)
+
+		// Add the month, if it is a new one.
+		if n := len(y.Months); n == 0 || y.Months[n-1].Month != month {
+			y.Months = append(y.Months, archiveMonth{
+				Year:  year,
+				Month: month,
+				Name:  m.translate(strings.ToLower(date.Month().String())),
+				route: fmt.Sprintf("%s%02d/", y.route, month),
+			})
+		}
+
+		mo := &y.Months[len(y.Months)-1]
+		mo.Posts = append(mo.Posts, p)
+	}
+}
+
+func (s *store) InitSections(m messages) {
+	s.sections = make(map[string]section, len(s.postsByCategory)+4)
 
 	// Create sections for each "category-grouping" of posts.
 	for cat := range s.postsByCategory {
 		s.sections[cat.Slug] = section{
 			Category: cat,
 			Posts:    s.postsByCategory[cat],
+			Title:    cat.Title,
 		}
 	}
 
@@ -137,18 +192,30 @@ func (s *store) InitSections() {
 	s.sections[sectionLatest] = section{
 		Category: category{
 			Slug:  "",
-			Title: "Latest Posts",
+			Title: m.translate("latest_posts"),
 		},
 		Posts: s.postsLatest,
+		Title: m.translate("latest_posts"),
 	}
 
 	// Create section for the "Featured Posts" pseudo-category.
 	s.sections[sectionFeatured] = section{
 		Category: category{
 			Slug:  "",
-			Title: "Featured Posts",
+			Title: m.translate("featured_posts"),
 		},
 		Posts: s.postsFeatured,
+		Title: m.translate("featured_posts"),
+	}
+
+	// Create section for the "Archive" pseudo-category.
+	s.sections[sectionArchive] = section{
+		Category: category{
+			Slug:  "",
+			Title: m.translate("archive"),
+		},
+		Archive: s.archive,
+		Title:   m.translate("archive"),
 	}
 
 	// Create section for the "All Posts" pseudo-category.
@@ -161,37 +228,63 @@ func (s *store) InitSections() {
 	}
 }
 
-func (s *store) RelatedPosts(p *post) []*post {
-	// If the post is a standalone post, then return nil.
-	if p.IsPage {
-		return nil
-	}
+// InitHomepage builds the homepage sections from the settings.
+// Unknown sections are reported as errors.
+// .
+func (s *store) InitHomepage(entries []homepageSection) error {
+	s.homepage = make([]section, 0, len(entries))
 
-	// Retrieve the posts for the category.
-	cPosts, ok := s.postsByCategory[p.Category]
+	for i := range entries {
+		h := &entries[i]
 
-	// If there are no posts for the category,
-	// then return nil.
-	if !ok {
-		return nil
+		sec, ok := s.sections[h.Section]
+		if !ok {
+			return fmt.Errorf("could not load homepage section [%s]", h.Section)
+		}
+
+		// The latest and featured sections start from all the posts,
+		// so that the limit and filters can go beyond the default lists.
+		switch h.Section {
+		case sectionLatest:
+			sec.Posts = h.posts(s.posts, false, limitLatest)
+		case sectionFeatured:
+			sec.Posts = h.posts(s.posts, true, limitFeatured)
+		case sectionArchive:
+		default:
+			sec.Posts = h.posts(sec.Posts, false, 0)
+		}
+
+		if h.Title != "" {
+			sec.Title = h.Title
+		}
+
+		sec.Display = h.Display
+		s.homepage = append(s.homepage, sec)
 	}
 
-	// If there are not enough posts in the category to gather,
-	// then return nil. This way, the number of category posts
-	// must cross the `limitRelated` threshold before they start
-	// being recommended.
-	if len(cPosts) <= limitRelated {
-		return nil
+	return nil
+}
+
+// InitRelated ranks the related posts of every blog post, once,
+// so that the post pages only look them up.
+// .
+func (s *store) InitRelated(count int) {
+	s.related = make(map[*post][]*post, len(s.posts))
+
+	if count <= 0 {
+		return
 	}
 
-	// Starting from the index of the current post,
-	// gather the next x number of posts for the category.
-	// Use modulo calculation to ensure the selection wraps
-	// around the category posts.
-	related := make([]*post, 0, limitRelated)
-	for i := 0; i < limitRelated; i++ {
-		related = append(related, cPosts[(p.idxCategory+i+1)%len(cPosts)])
+	idx := newRelatedIndex(s.posts)
+
+	for _, p := range s.posts {
+		s.related[p] = idx.rank(p, count)
 	}
+}
 
-	return related
+// RelatedPosts returns the ranked related posts.
+// Standalone posts (pages) have no related posts.
+// .
+func (s *store) RelatedPosts(p *post) []*post {
+	return s.related[p]
 }
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/fs"
@@ -24,6 +26,34 @@ func Slugify(s string) string {
 	return strings.TrimSpace(strings.ToLower(strings.ReplaceAll(s, " ", "-")))
 }
 
+// Hash returns a short content hash for the given data.
+// This is used to fingerprint asset filenames.
+// .
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:10]
+}
+
+// FormatBytes formats a byte count as a human-readable size.
+//
+// ex: 1536  =>  "1.5 KB"
+// .
+func FormatBytes(n int) string {
+	const unit = 1024
+
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := unit, 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGT"[exp])
+}
+
 // SafeDir returns a filepath directory.
 // If the given path is a file, then the parent directory of the file will be returned.
 // If the given path is a directory, then the directory itself will be returned.
@@ -109,6 +139,36 @@ func WriteFile(source string, data []byte) error {
 	return nil
 }
 
+// ReplaceDir replaces the destination directory with the source directory,
+// by renaming it. The previous destination is removed, or restored if the
+// rename fails. Both directories must be on the same filesystem.
+// .
+func ReplaceDir(src, dst string) error {
+	old := filepath.Join(filepath.Dir(dst), "."+filepath.Base(dst)+"-old")
+
+	// Remove the leftovers of an interrupted replace.
+	if err := os.RemoveAll(old); err != nil {
+		return err
+	}
+
+	// Move the previous destination aside, if it exists.
+	if Exists(dst) {
+		if err := os.Rename(dst, old); err != nil {
+			return fmt.Errorf("failed to replace directory: %w", err)
+		}
+	}
+
+	if err := os.Rename(src, dst); err != nil {
+		// Restore the previous destination.
+		if Exists(old) {
+			os.Rename(old, dst)
+		}
+		return fmt.Errorf("failed to replace directory: %w", err)
+	}
+
+	return os.RemoveAll(old)
+}
+
 // CopyDir recursively copies a directory to a destination.
 // .
 func CopyDir(fsys fs.FS, root, dst string) error {
@@ -202,17 +262,21 @@ func Glob(inputFS fs.FS, root, glob string) ([]string, error) {
 }
 
 // LocalGlob returns a list of files matching the given extensions,
-// starting from the root directory.
+// starting from the root directory. The root can be outside of the
+// current directory, ex: "../posts".
 // .
 func LocalGlob(root string, ext ...string) ([]string, error) {
 	ret := make([]string, 0, 10)
 
 	for _, ext := range ext {
-		paths, err := Glob(os.DirFS("."), root, "**/*."+ext)
+		paths, err := Glob(os.DirFS(root), ".", "**/*."+ext)
 		if err != nil {
 			return nil, err
 		}
-		ret = append(ret, paths...)
+
+		for _, p := range paths {
+			ret = append(ret, filepath.Join(root, p))
+		}
 	}
 
 	return ret, nil
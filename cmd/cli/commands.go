@@ -0,0 +1,503 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"gingersnap/app"
+	"gingersnap/app/utils"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Type: command
+//
+//
+// ------------------------------------------------------------------
+
+// command describes a cli command, with its flags and arguments.
+// .
+type command struct {
+	name    string
+	summary string
+
+	// The arguments for the help text, ex: `[post|page] "Title"`
+	args string
+
+	// The number of accepted arguments
+	minArgs int
+	maxArgs int
+
+	// The accepted flags, see `flagDefs`
+	flags []string
+
+	// The argument values for the shell completion
+	words []string
+
+	run func(ctx context.Context, g *app.Gingersnap, o *options, args []string) error
+}
+
+// findCommand returns the command with the name, or nil.
+// .
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// accepts reports if the command accepts the flag.
+// .
+func (cmd *command) accepts(flag string) bool {
+	for _, name := range cmd.flags {
+		if name == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// flagSet returns a flag set with the command's flags, bound to the options.
+// .
+func (cmd *command) flagSet(o *options) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	for _, name := range cmd.flags {
+		flagDefs[name].register(fs, o)
+	}
+
+	return fs
+}
+
+// writeHelp writes the command usage, summary and flags.
+// .
+func (cmd *command) writeHelp(w io.Writer) {
+	usage := "gingersnap " + cmd.name
+	if cmd.args != "" {
+		usage += " " + cmd.args
+	}
+	if len(cmd.flags) > 0 {
+		usage += " [flags]"
+	}
+
+	fmt.Fprintf(w, "\nUsage:\n  %s\n\n%s\n", usage, cmd.summary)
+
+	if len(cmd.flags) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "\nFlags:")
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, name := range cmd.flags {
+		def := flagDefs[name]
+
+		fmt.Fprintf(tw, "  --%s %s\t%s", def.name, def.value, def.usage)
+		if def.defaultVal != "" {
+			fmt.Fprintf(tw, " (default %q)", def.defaultVal)
+		}
+		fmt.Fprintln(tw)
+	}
+	fmt.Fprintf(tw, "  --help\tShow this help\n")
+	tw.Flush()
+}
+
+// ------------------------------------------------------------------
+//
+//
+// Commands
+//
+//
+// ------------------------------------------------------------------
+
+// The cli commands, in the order of the help text.
+// They are set in `init`, since the completion command refers to them.
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{
+			name:    "init",
+			summary: "Create a new project, and scaffold the required assets",
+			run:     runInit,
+		},
+		{
+			name:    "new",
+			summary: "Create a post or page, ex: 'gingersnap new post \"Some Title\"'",
+			args:    `[post|page] "Title"`,
+			minArgs: 2,
+			maxArgs: 2,
+			flags:   append([]string{"category"}, projectFlags...),
+			words:   []string{"post", "page"},
+			run:     runNew,
+		},
+		{
+			name:    "dev",
+			summary: "Start the dev server, and reload on file changes",
+			flags:   append([]string{"addr", "stats", "stats-json"}, projectFlags...),
+			run:     runDev,
+		},
+		{
+			name:    "themes",
+			summary: "List the built-in and custom color themes",
+			flags:   projectFlags,
+			run:     runThemes,
+		},
+		{
+			name:    "webp",
+			summary: "Convert images to webp format",
+			flags:   []string{"media", "config"},
+			run:     runWebp,
+		},
+		{
+			name:    "export",
+			summary: "Export the project as a static site",
+			flags:   append([]string{"out", "stats", "stats-json"}, projectFlags...),
+			run:     runExport,
+		},
+		{
+			name:    "serve",
+			summary: "Serve the exported site, ex: 'gingersnap serve dist'",
+			args:    "[dir]",
+			maxArgs: 1,
+			flags:   []string{"out", "addr", "config", "verbose", "log-format", "log-level"},
+			run:     runServe,
+		},
+		{
+			name:    "deploy",
+			summary: "Export the project, and publish it to the deploy target",
+			flags:   append([]string{"out", "dry-run"}, projectFlags...),
+			run:     runDeploy,
+		},
+		{
+			name:    "clean",
+			summary: "Remove temp files and dirs",
+			flags:   []string{"out", "config"},
+			run:     runClean,
+		},
+		{
+			name:    "completion",
+			summary: "Write the shell completion script, ex: 'gingersnap completion bash'",
+			args:    "[bash|zsh|fish]",
+			minArgs: 1,
+			maxArgs: 1,
+			words:   shells,
+			run:     runCompletion,
+		},
+		{
+			name:    "version",
+			summary: "View build info",
+			run:     runVersion,
+		},
+	}
+}
+
+// ----------------------------------------------------------
+//
+//
+// Version - View build info
+//
+//
+// ----------------------------------------------------------
+
+func runVersion(ctx context.Context, g *app.Gingersnap, o *options, args []string) error {
+	loginfo(o.stdout, "\nGingersnap")
+	loginfo(o.stdout, "  Built At    %s", BuildDate)
+	loginfo(o.stdout, "  Git Hash    %s\n", BuildHash)
+
+	return nil
+}
+
+// ----------------------------------------------------------
+//
+//
+// Init - Create a new project. Scaffold assets.
+//
+//
+// ----------------------------------------------------------
+
+func runInit(ctx context.Context, g *app.Gingersnap, o *options, args []string) error {
+	// If the config exists in the current directory,
+	// then do not scaffold a new project here.
+	if utils.Exists(g.ConfigPath) {
+		return fmt.Errorf("project already initialized, %s exists", g.ConfigPath)
+	}
+
+	// Use the default project paths, since there is no config yet.
+	if err := g.ResolvePaths(); err != nil {
+		return err
+	}
+
+	// Copy embedded resources into the project paths.
+	if err := g.Unpack(); err != nil {
+		return err
+	}
+
+	loginfo(o.stdout, "Gingersnap project initialized ✅")
+
+	return nil
+}
+
+// ----------------------------------------------------------
+//
+//
+// New - Create a post or page from an archetype.
+//
+//
+// ----------------------------------------------------------
+
+func runNew(ctx context.Context, g *app.Gingersnap, o *options, args []string) error {
+	// Check that the project files exist.
+	if err := ensureProject(g); err != nil {
+		return err
+	}
+
+	// Configure the gingersnap engine.
+	if err := g.Configure(ctx); err != nil {
+		return err
+	}
+
+	filePath, err := g.NewContent(args[0], args[1], o.category)
+	if err != nil {
+		return err
+	}
+
+	loginfo(o.stdout, "Created %s ✅", filePath)
+
+	return nil
+}
+
+// ----------------------------------------------------------
+//
+//
+// Dev - Start the dev server, and reload on file changes.
+//
+//
+// ----------------------------------------------------------
+
+func runDev(ctx context.Context, g *app.Gingersnap, o *options, args []string) error {
+	// Check that the project files exist.
+	if err := ensureProject(g); err != nil {
+		return err
+	}
+
+	// Configure the gingersnap engine.
+	if err := g.Configure(ctx); err != nil {
+		return err
+	}
+
+	// Report the build stats.
+	if err := writeStats(g, o); err != nil {
+		return err
+	}
+
+	// Run the server with file watcher.
+	return runServerWithWatcher(ctx, g, o)
+}
+
+// ----------------------------------------------------------
+//
+//
+// Themes - List the built-in and custom color themes.
+//
+//
+// ----------------------------------------------------------
+
+func runThemes(ctx context.Context, g *app.Gingersnap, o *options, args []string) error {
+	// Check that the project files exist.
+	if err := ensureProject(g); err != nil {
+		return err
+	}
+
+	// Configure the gingersnap engine.
+	if err := g.Configure(ctx); err != nil {
+		return err
+	}
+
+	// Write the themes table.
+	if err := g.WriteThemes(o.stdout); err != nil {
+		return err
+	}
+
+	loginfo(o.stdout, "\nPreview the themes at /themes/ with 'gingersnap dev'")
+
+	return nil
+}
+
+// ----------------------------------------------------------
+//
+//
+// Webp - Convert images to webp format.
+//
+//
+// ----------------------------------------------------------
+
+func runWebp(ctx context.Context, g *app.Gingersnap, o *options, args []string) error {
+	// Check that the media directory exists.
+	if !utils.Exists(g.MediaPath) {
+		return fmt.Errorf("no media directory detected at %s", g.MediaPath)
+	}
+
+	w := app.NewWebp()
+
+	// Gather the images in the media directory.
+	imgPaths, err := utils.LocalGlob(g.MediaPath, "png", "jpg", "jpeg")
+	if err != nil {
+		return err
+	}
+
+	// Convert all the images in the media directory to webp.
+	return w.Convert(imgPaths...)
+}
+
+// ----------------------------------------------------------
+//
+//
+// Export - Export the project as a static site.
+//
+//
+// ----------------------------------------------------------
+
+func runExport(ctx context.Context, g *app.Gingersnap, o *options, args []string) error {
+	// Check that the project files exist.
+	if err := ensureProject(g); err != nil {
+		return err
+	}
+
+	g.Debug = false
+
+	// Configure the gingersnap engine.
+	if err := g.Configure(ctx); err != nil {
+		return err
+	}
+
+	// Export the site.
+	if err := g.Export(ctx); err != nil {
+		return err
+	}
+
+	// Report the build and export stats.
+	if err := writeStats(g, o); err != nil {
+		return err
+	}
+
+	loginfo(o.stdout, "Site export complete ✅")
+
+	return nil
+}
+
+// ----------------------------------------------------------
+//
+//
+// Serve - Serve the exported site, for a production preview.
+//
+//
+// ----------------------------------------------------------
+
+func runServe(ctx context.Context, g *app.Gingersnap, o *options, args []string) error {
+	// The export directory, ex: `gingersnap serve dist`
+	dir := g.ExportPath
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	if !utils.Exists(dir) {
+		return fmt.Errorf("dir %s does not exist. Run 'gingersnap export' first", dir)
+	}
+
+	return g.ServeExport(ctx, dir, g.ListenAddr)
+}
+
+// ----------------------------------------------------------
+//
+//
+// Deploy - Export the project, and publish it to the deploy target.
+//
+//
+// ----------------------------------------------------------
+
+func runDeploy(ctx context.Context, g *app.Gingersnap, o *options, args []string) error {
+	// Check that the project files exist.
+	if err := ensureProject(g); err != nil {
+		return err
+	}
+
+	g.Debug = false
+
+	// Configure the gingersnap engine.
+	if err := g.Configure(ctx); err != nil {
+		return err
+	}
+
+	// Export the site.
+	//
+	loginfo(o.stdout, "[1/3] Exporting the site")
+	if err := g.Export(ctx); err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+
+	// Deploy the changes to the configured target.
+	//
+	loginfo(o.stdout, "[2/3] Deploying changes")
+	if err := g.Deploy(o.stdout, o.dryRun); err != nil {
+		return err
+	}
+
+	if o.dryRun {
+		loginfo(o.stdout, "\nDry run complete. Nothing was deployed, and the export was kept in %s", g.ExportPath)
+		return nil
+	}
+
+	// Remove the exported site from the project directory.
+	//
+	loginfo(o.stdout, "[3/3] Cleaning up")
+	if err := os.RemoveAll(g.ExportPath); err != nil {
+		return err
+	}
+
+	loginfo(o.stdout, "Site export and deploy complete ✅")
+
+	return nil
+}
+
+// ----------------------------------------------------------
+//
+//
+// Clean - Remove temp files and dirs
+//
+//
+// ----------------------------------------------------------
+
+func runClean(ctx context.Context, g *app.Gingersnap, o *options, args []string) error {
+	loginfo(o.stdout, "[1/2] Remove temp directories")
+	if err := os.RemoveAll(g.ExportPath); err != nil {
+		return err
+	}
+
+	loginfo(o.stdout, "[2/2] Remove helper directories")
+	w := app.NewWebp()
+
+	// Remove the `cwebp` binary.
+	return w.Clean()
+}
+
+// ----------------------------------------------------------
+//
+//
+// Completion - Write the shell completion script.
+//
+//
+// ----------------------------------------------------------
+
+func runCompletion(ctx context.Context, g *app.Gingersnap, o *options, args []string) error {
+	return writeCompletion(o.stdout, strings.ToLower(args[0]))
+}
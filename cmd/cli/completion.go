@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ------------------------------------------------------------------
+//
+//
+// Shell completion
+//
+//
+// ------------------------------------------------------------------
+
+// The shells with a completion script.
+var shells = []string{"bash", "zsh", "fish"}
+
+// writeCompletion writes the completion script for the shell.
+// The scripts complete the commands, their flags and their arguments.
+//
+// ex:
+//
+//	source <(gingersnap completion bash)
+//
+// .
+func writeCompletion(w io.Writer, shell string) error {
+	switch shell {
+	case "bash":
+		writeBashCompletion(w)
+	case "zsh":
+		writeZshCompletion(w)
+	case "fish":
+		writeFishCompletion(w)
+	default:
+		return fmt.Errorf("unknown shell [%s], use one of [%s]", shell, strings.Join(shells, ", "))
+	}
+	return nil
+}
+
+// completionWords returns the flags and argument values of the command.
+// .
+func completionWords(cmd *command) []string {
+	words := make([]string, 0, len(cmd.flags)+len(cmd.words)+1)
+
+	for _, name := range cmd.flags {
+		words = append(words, "--"+name)
+	}
+	words = append(words, "--help")
+
+	return append(words, cmd.words...)
+}
+
+func writeBashCompletion(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for _, cmd := range commands {
+		names = append(names, cmd.name)
+	}
+
+	fmt.Fprintln(w, "# bash completion for gingersnap")
+	fmt.Fprintln(w, "_gingersnap() {")
+	fmt.Fprintln(w, `	local cur="${COMP_WORDS[COMP_CWORD]}"`)
+	fmt.Fprintln(w, `	if [ "$COMP_CWORD" -eq 1 ]; then`)
+	fmt.Fprintf(w, "\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	fmt.Fprintln(w, "\t\treturn")
+	fmt.Fprintln(w, "\tfi")
+	fmt.Fprintln(w, `	case "${COMP_WORDS[1]}" in`)
+
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "\t\t%s) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", cmd.name, strings.Join(completionWords(cmd), " "))
+	}
+
+	fmt.Fprintln(w, "\tesac")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -o default -F _gingersnap gingersnap")
+}
+
+func writeZshCompletion(w io.Writer) {
+	fmt.Fprintln(w, "#compdef gingersnap")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "_gingersnap() {")
+	fmt.Fprintln(w, "\tlocal -a commands")
+	fmt.Fprintln(w, "\tcommands=(")
+
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "\t\t%s\n", shellQuote(cmd.name+":"+cmd.summary))
+	}
+
+	fmt.Fprintln(w, "\t)")
+	fmt.Fprintln(w, "\tif (( CURRENT == 2 )); then")
+	fmt.Fprintln(w, "\t\t_describe 'command' commands")
+	fmt.Fprintln(w, "\t\treturn")
+	fmt.Fprintln(w, "\tfi")
+	fmt.Fprintln(w, `	case "$words[2]" in`)
+
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "\t\t%s) compadd -- %s; _files ;;\n", cmd.name, strings.Join(completionWords(cmd), " "))
+	}
+
+	fmt.Fprintln(w, "\tesac")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `_gingersnap "$@"`)
+}
+
+func writeFishCompletion(w io.Writer) {
+	fmt.Fprintln(w, "# fish completion for gingersnap")
+	fmt.Fprintln(w, "complete -c gingersnap -f")
+
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "complete -c gingersnap -n __fish_use_subcommand -a %s -d %s\n", cmd.name, shellQuote(cmd.summary))
+	}
+
+	for _, cmd := range commands {
+		cond := shellQuote("__fish_seen_subcommand_from " + cmd.name)
+
+		for _, name := range cmd.flags {
+			def := flagDefs[name]
+
+			// The flags with a file or dir value complete file names.
+			value := ""
+			switch def.value {
+			case "":
+			case "file", "dir":
+				value = " -rF"
+			default:
+				value = " -r"
+			}
+			fmt.Fprintf(w, "complete -c gingersnap -n %s -l %s%s -d %s\n", cond, def.name, value, shellQuote(def.usage))
+		}
+
+		if len(cmd.words) > 0 {
+			fmt.Fprintf(w, "complete -c gingersnap -n %s -a %s\n", cond, shellQuote(strings.Join(cmd.words, " ")))
+		}
+	}
+}
+
+// shellQuote quotes the string for a shell, with single quotes.
+//
+// ex:
+//
+//	it's  =>  'it'\''s'
+//
+// .
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
-	"path/filepath"
-	"time"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
 
 	"github.com/fsnotify/fsnotify"
 
@@ -14,229 +19,244 @@ import (
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		loginfo(helpText)
-		os.Exit(0)
-	}
-
-	g := app.NewGingersnap()
-	g.Debug = true
-	g.ConfigPath = "gingersnap.json"
-	g.PostsPath = "posts"
-	g.MediaPath = "media"
-	g.ExportPath = "dist"
-
-	switch os.Args[1] {
-	case "version":
-
-		// ----------------------------------------------------------
-		//
-		//
-		// Version - View build info
-		//
-		//
-		// ----------------------------------------------------------
-
-		loginfo("\nGingersnap")
-		loginfo("  Built At    %s", BuildDate)
-		loginfo("  Git Hash    %s\n", BuildHash)
-
-	case "init":
-
-		// ----------------------------------------------------------
-		//
-		//
-		// Init - Create a new project. Scaffold assets.
-		//
-		//
-		// ----------------------------------------------------------
-
-		// If the config exists in the current directory,
-		// then do not scaffold a new project here.
-		if utils.Exists(g.ConfigPath) {
-			logerr("Project already initialized. Skipping.")
-		}
-
-		// Copy embedded resources into the current directory.
-		g.Unpack()
-
-		loginfo("Gingersnap project initialized ✅")
+	// Cancel the command on ctrl-c or on termination, so that
+	// the servers stop and the builds are left unfinished.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 
-	case "dev":
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
 
-		// ----------------------------------------------------------
-		//
-		//
-		// Dev - Start the dev server, and reload on file changes.
-		//
-		//
-		// ----------------------------------------------------------
+	os.Exit(code)
+}
 
-		// Check that the project files exist.
-		ensureProject(g)
+// run runs the command in the args, and returns the exit code.
+// .
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || isHelp(args[0]) {
+		writeHelp(stdout)
+		return exitOK
+	}
 
-		// Configure the gingersnap engine.
-		g.Configure()
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		fmt.Fprintf(stderr, "Unknown command '%s'\n", args[0])
+		fmt.Fprintln(stderr, "Run 'gingersnap --help' for help with usage")
+		return exitUsage
+	}
 
-		// Run the server with file watcher.
-		runServerWithWatcher(g)
+	// Parse the command flags.
+	o := newOptions()
+	o.stdout = stdout
+	fs := cmd.flagSet(o)
 
-	case "webp":
+	cmdArgs, err := parseFlags(fs, args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		cmd.writeHelp(stdout)
+		return exitOK
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "%s\n", err)
+		cmd.writeHelp(stderr)
+		return exitUsage
+	}
 
-		// ----------------------------------------------------------
-		//
-		//
-		// Webp - Convert images to webp format.
-		//
-		//
-		// ----------------------------------------------------------
+	if n := len(cmdArgs); n < cmd.minArgs || n > cmd.maxArgs {
+		fmt.Fprintf(stderr, "Wrong number of arguments for '%s'\n", cmd.name)
+		cmd.writeHelp(stderr)
+		return exitUsage
+	}
 
-		// Check that the project files exist.
-		ensureProject(g)
+	// Construct the logger. The verbose flag is a shortcut for the debug level.
+	if o.verbose {
+		o.logLevel = "debug"
+	}
 
-		w := app.NewWebp()
+	logger, err := app.NewLogger(stderr, o.logFormat, o.logLevel)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s\n", err)
+		cmd.writeHelp(stderr)
+		return exitUsage
+	}
 
-		// Gather the images in the media directory.
-		imgPaths, err := utils.LocalGlob(g.MediaPath, "png", "jpg", "jpeg")
-		if err != nil {
-			logerr("webp error: %s", err)
+	// Construct the gingersnap engine.
+	g := app.NewGingersnap()
+	g.Logger = logger
+	g.Debug = true
+	g.ConfigPath = o.config
+	g.PostsPath = o.posts
+	g.MediaPath = o.media
+	g.ExportPath = o.out
+	g.ListenAddr = o.addr
+	g.Version = BuildHash
+	g.MessagesPath = ""
+	g.ArchetypesPath = ""
+
+	// Search for the project's config, in the current directory and
+	// its parents. A new project is always created in the current directory.
+	if g.ConfigPath == "" {
+		g.ConfigPath = "gingersnap.json"
+
+		if cmd.accepts("config") {
+			if configPath, err := app.FindConfig("."); err == nil {
+				g.ConfigPath = configPath
+			}
 		}
+	}
 
-		// Convert all the images in the media directory to webp.
-		if err := w.Convert(imgPaths...); err != nil {
-			logerr("webp error: %s", err)
+	// The paths which are not given as flags are resolved from the config,
+	// for the project commands. The other commands never read the config.
+	if cmd.accepts("config") {
+		if err := g.ResolvePaths(); err != nil {
+			logger.Error(cmd.name+" failed", "error", err)
+			return exitError
 		}
+	}
 
-	case "export":
+	// Run the command.
+	err = cmd.run(ctx, g, o, cmdArgs)
 
-		// ----------------------------------------------------------
-		//
-		//
-		// Export - Export the project as a static site.
-		//
-		//
-		// ----------------------------------------------------------
+	if errors.Is(err, context.Canceled) {
+		logger.Warn(cmd.name + " canceled")
+		return exitCanceled
+	}
 
-		// Check that the project files exist.
-		ensureProject(g)
+	if err != nil {
+		logger.Error(cmd.name+" failed", "error", err)
+		return exitError
+	}
 
-		g.Debug = false
+	return exitOK
+}
 
-		// Configure the gingersnap engine.
-		g.Configure()
+// ------------------------------------------------------------------
+//
+//
+// Type: options
+//
+//
+// ------------------------------------------------------------------
 
-		// Export the site.
-		if err := g.Export(); err != nil {
-			logerr("export error: %s", err)
-		}
+// options stores the values of the command flags, and the command output.
+// Each command only accepts some of the flags, see `command.flags`.
+// .
+type options struct {
+	config    string
+	posts     string
+	media     string
+	out       string
+	addr      string
+	verbose   bool
+	logFormat string
+	logLevel  string
+	stats     bool
+	statsJson string
+	dryRun    bool
+	category  string
+
+	// The writer for the command output, like the stats table
+	stdout io.Writer
+}
 
-		loginfo("Site export complete ✅")
+// newOptions returns the options with the default flag values, so that
+// the commands which do not accept a flag still get its default.
+// .
+func newOptions() *options {
+	o := &options{}
 
-	case "deploy":
+	fs := flag.NewFlagSet("defaults", flag.ContinueOnError)
+	for _, def := range flagDefs {
+		def.register(fs, o)
+	}
 
-		// ----------------------------------------------------------
-		//
-		//
-		// Deploy - Export the project, and push it to a dedicated repository.
-		//
-		//
-		// ----------------------------------------------------------
+	return o
+}
 
-		// Check that the project files exist.
-		ensureProject(g)
+// flagDef describes a command flag.
+// .
+type flagDef struct {
+	name  string
+	usage string
 
-		g.Debug = false
+	// The value placeholder for the help text, or empty for a bool flag
+	value string
 
-		// Configure the gingersnap engine.
-		g.Configure()
+	// The default value for the help text
+	defaultVal string
 
-		// [1/2] Preliminary checks ---------------------------------
+	// Registers the flag on the flag set
+	register func(fs *flag.FlagSet, o *options)
+}
 
-		// The gingersnap project directory.
-		projectDir := currentDir()
+func stringFlag(name, value, defaultVal, usage string, field func(o *options) *string) flagDef {
+	return flagDef{
+		name:       name,
+		usage:      usage,
+		value:      value,
+		defaultVal: defaultVal,
+		register: func(fs *flag.FlagSet, o *options) {
+			fs.StringVar(field(o), name, defaultVal, usage)
+		},
+	}
+}
 
-		// The git repository where the production static site will be stored.
-		repository := g.Repository()
+func boolFlag(name, usage string, field func(o *options) *bool) flagDef {
+	return flagDef{
+		name:  name,
+		usage: usage,
+		register: func(fs *flag.FlagSet, o *options) {
+			fs.BoolVar(field(o), name, false, usage)
+		},
+	}
+}
 
-		if repository == "" {
-			logerr("Please specify a git repository to deploy the site to.")
-		}
+// The flags which the commands can accept, by name.
+var flagDefs = map[string]flagDef{
+	"config":     stringFlag("config", "file", "", "The config file (default is the nearest gingersnap.json)", func(o *options) *string { return &o.config }),
+	"posts":      stringFlag("posts", "dir", "", "The posts directory (default is the config's postsDir)", func(o *options) *string { return &o.posts }),
+	"media":      stringFlag("media", "dir", "", "The media directory (default is the config's mediaDir)", func(o *options) *string { return &o.media }),
+	"out":        stringFlag("out", "dir", "", "The export directory (default is the config's outputDir)", func(o *options) *string { return &o.out }),
+	"addr":       stringFlag("addr", "addr", ":4000", "The address for the server to listen on", func(o *options) *string { return &o.addr }),
+	"category":   stringFlag("category", "title", "", "The post's category (default is the category with the most posts)", func(o *options) *string { return &o.category }),
+	"verbose":    boolFlag("verbose", "Log at the debug level, like every exported page", func(o *options) *bool { return &o.verbose }),
+	"log-format": stringFlag("log-format", "format", "text", "The log format: text or json", func(o *options) *string { return &o.logFormat }),
+	"stats":      boolFlag("stats", "Report the build timings and sizes", func(o *options) *bool { return &o.stats }),
+	"stats-json": stringFlag("stats-json", "file", "", "Write the build timings and sizes as json to the file", func(o *options) *string { return &o.statsJson }),
+	"log-level":  stringFlag("log-level", "level", "info", "The log level: debug, info, warn or error", func(o *options) *string { return &o.logLevel }),
+	"dry-run":    boolFlag("dry-run", "Preview the changes, without deploying them", func(o *options) *bool { return &o.dryRun }),
+}
 
-		if projectDir == repository {
-			logerr("Cannot push site to current directory. You must specify another git repository.")
-		}
+// The flags for the commands which load the project.
+var projectFlags = []string{"config", "posts", "media", "verbose", "log-format", "log-level"}
 
-		if !utils.Exists(repository) {
-			logerr("Dir %s does not exist.", repository)
-		}
+// parseFlags parses the flags, which can be given before,
+// between or after the arguments, and returns the arguments.
+// Everything after a `--` is an argument.
+//
+// ex: `new post "Some Title" --category Go`
+// .
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	rest := []string{}
 
-		if !utils.Exists(filepath.Join(repository, ".git")) {
-			logerr("Dir %s is not a git repository.", repository)
+	for i, arg := range args {
+		if arg == "--" {
+			args, rest = args[:i], args[i+1:]
+			break
 		}
+	}
 
-		// [2/2] Export and Deploy ----------------------------------
+	positional := []string{}
 
-		// Export the site.
-		//
-		loginfo("[1/6] Exporting the site")
-		if err := g.Export(); err != nil {
-			logerr("export error: %s", err)
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
 		}
 
-		// Remove all content from the prod repo directory.
-		//
-		loginfo("[2/6] Removing static site contents")
-		chdir(repository)
-		command("git", "rm", "-rf", "--ignore-unmatch", "--quiet", ".")
-		chdir(projectDir)
-
-		// Copy the exported site to the prod repo directory.
-		//
-		loginfo("[3/6] Copying into repository")
-		utils.CopyDir(os.DirFS(g.ExportPath), ".", repository)
-
-		// Navigate to the prod repo and commit the changes.
-		//
-		loginfo("[4/6] Commiting changes")
-		chdir(repository)
-		command("git", "add", "-A")
-		command("git", "commit", "-m", fmt.Sprintf("Updated site on %s", time.Now().Format(time.UnixDate)))
-		loginfo("[5/6] Pushing site upstream")
-		command("git", "push", "-f", "origin", "main")
-		chdir(projectDir)
-
-		// Remove the exported site from the project directory.
-		//
-		loginfo("[6/6] Cleaning up")
-		remove(g.ExportPath)
-
-		loginfo("Site export and deploy complete ✅")
-
-	case "clean":
-
-		// ----------------------------------------------------------
-		//
-		//
-		// Clean - Remove temp files and dirs
-		//
-		//
-		// ----------------------------------------------------------
-
-		loginfo("[1/2] Remove temp directories")
-		remove(g.ExportPath)
-
-		loginfo("[2/2] Remove helper directories")
-		w := app.NewWebp()
-
-		// Remove the `cwebp` binary.
-		if err := w.Clean(); err != nil {
-			logerr("webp error: %s", err)
+		if fs.NArg() == 0 {
+			return append(positional, rest...), nil
 		}
 
-	default:
-		loginfo("Unknown command '%s'", os.Args[1])
-		loginfo("Run 'gingersnap' for help with usage")
-		loginfo("")
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
 	}
 }
 
@@ -248,46 +268,16 @@ func main() {
 //
 // ------------------------------------------------------------------
 
-func currentDir() string {
-	dir, err := os.Getwd()
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
-	return dir
-}
-
-func command(cmds ...string) {
-	_, err := exec.Command(cmds[0], cmds[1:]...).Output()
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
-}
-
-func chdir(source string) {
-	if err := os.Chdir(source); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
+func isHelp(arg string) bool {
+	return arg == "-h" || arg == "--help" || arg == "help"
 }
 
-func remove(source string) {
-	if err := os.RemoveAll(source); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
-}
-
-func loginfo(msg string, args ...any) {
+// loginfo writes a formatted line of command output to w,
+// which is the run's stdout.
+// .
+func loginfo(w io.Writer, msg string, args ...any) {
 	formattedMsg := fmt.Sprintf(msg, args...)
-	fmt.Printf("%s\n", formattedMsg)
-}
-
-func logerr(msg string, args ...any) {
-	fmt.Printf(msg, args...)
-	fmt.Println()
-	os.Exit(1)
+	fmt.Fprintln(w, formattedMsg)
 }
 
 // ------------------------------------------------------------------
@@ -298,33 +288,67 @@ func logerr(msg string, args ...any) {
 //
 // ------------------------------------------------------------------
 
+// writeHelp writes the usage and the list of commands.
+// .
+func writeHelp(w io.Writer) {
+	fmt.Fprint(w, helpText)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", cmd.name, cmd.summary)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w, "\nRun 'gingersnap <command> --help' for the command's flags.")
+}
+
+// writeStats writes the build stats, if requested by the flags.
+// .
+func writeStats(g *app.Gingersnap, o *options) error {
+	if o.stats {
+		if err := g.WriteStats(o.stdout, "table"); err != nil {
+			return err
+		}
+	}
+
+	if o.statsJson != "" {
+		buf := new(bytes.Buffer)
+		if err := g.WriteStats(buf, "json"); err != nil {
+			return err
+		}
+		return utils.WriteFile(o.statsJson, buf.Bytes())
+	}
+
+	return nil
+}
+
 // ensureProject checks that the required configs/dirs
 // exist for the gingersnap engine.
 // .
-func ensureProject(g *app.Gingersnap) {
-	// If the config does not exist in the current directory,
-	// then do not start the server.
+func ensureProject(g *app.Gingersnap) error {
+	// If the config does not exist, then do not continue.
 	if !utils.Exists(g.ConfigPath) {
-		logerr("No config detected. Skipping.")
+		return fmt.Errorf("no config detected at %s. Run 'gingersnap init' first", g.ConfigPath)
 	}
 
-	// If the media directory does not exist in the current directory,
-	// then do not start the server.
+	// If the media directory does not exist, then do not continue.
 	if !utils.Exists(g.MediaPath) {
-		logerr("No media directory detected. Skipping.")
+		return fmt.Errorf("no media directory detected at %s", g.MediaPath)
 	}
 
-	// If the posts directory does not exist in the current directory,
-	// then do not start the server.
+	// If the posts directory does not exist, then do not continue.
 	if !utils.Exists(g.PostsPath) {
-		logerr("No posts directory detected. Skipping.")
+		return fmt.Errorf("no posts directory detected at %s", g.PostsPath)
 	}
+
+	return nil
 }
 
 // runServerWithWatcher runs the server and and watches for file changes.
 // On file change, it resets the gingersnap engine and restarts the server.
+// The server stops when the context is canceled.
 // .
-func runServerWithWatcher(g *app.Gingersnap) error {
+func runServerWithWatcher(ctx context.Context, g *app.Gingersnap, o *options) error {
 	// Create new watcher.
 	w, err := fsnotify.NewWatcher()
 	if err != nil {
@@ -343,18 +367,52 @@ func runServerWithWatcher(g *app.Gingersnap) error {
 		return err
 	}
 
-	fmt.Println("Watching for file changes")
+	// The message files and the template overrides are optional.
+	for _, dir := range []string{g.MessagesPath, g.TemplatesPath} {
+		if dir != "" && utils.Exists(dir) {
+			if err = w.Add(utils.SafeDir(dir)); err != nil {
+				return err
+			}
+		}
+	}
+
+	g.Logger.Info("watching for file changes")
+
+	// runServer runs the server, and returns the channel of its result.
+	// A server which is stopped by a restart returns no error.
+	runServer := func() <-chan error {
+		done := make(chan error, 1)
+		go func() {
+			done <- g.RunServer(ctx)
+		}()
+		return done
+	}
 
-	go g.RunServer()
+	done := runServer()
 
 	for {
 		select {
+		case err := <-done:
+			// The server stopped, or could not listen.
+			return err
+
 		case event := <-w.Events:
 			if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) || event.Has(fsnotify.Rename) {
-				fmt.Println("Files changed. Restarting server")
+				g.Logger.Info("files changed, restarting server", "file", event.Name, "op", event.Op.String())
+
+				// Keep serving the previous build, if the new one fails.
+				if err := g.Configure(ctx); err != nil {
+					if ctx.Err() == nil {
+						g.Logger.Error("rebuild failed", "error", err)
+					}
+					continue
+				}
 
-				g.Configure()
-				go g.RunServer()
+				if err := writeStats(g, o); err != nil {
+					return err
+				}
+
+				done = runServer()
 			}
 		case err := <-w.Errors:
 			return err
@@ -370,21 +428,23 @@ func runServerWithWatcher(g *app.Gingersnap) error {
 //
 // ------------------------------------------------------------------
 
+// The exit codes. A usage error is a wrong command, flag or argument.
+// A canceled command was stopped by ctrl-c, like the shells report it.
+const (
+	exitOK       = 0
+	exitError    = 1
+	exitUsage    = 2
+	exitCanceled = 130
+)
+
 var helpText = `
 This is the command line interface for Gingersnap,
 a simple and opinionated static site generator.
 
 Usage:
-  gingersnap [command]
+  gingersnap <command> [flags]
 
 Commands:
-  init        Create a new project, and scaffold the required assets
-  dev         Start the dev server, and reload on file changes
-  webp        Convert images to webp format
-  export      Export the project as a static site
-  deploy      Export the project, and push it to a dedicated repository
-  clean       Remove temp files and dirs
-  version     View build info
 `
 
 var (
@@ -1,9 +1,24 @@
 package main
 
-import "gingersnap/app"
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"gingersnap/app"
+)
 
 func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	g := app.NewGingersnap()
-	g.Configure()
-	g.RunServer()
+	if err := g.Configure(ctx); err != nil {
+		log.Fatal(err)
+	}
+	if err := g.RunServer(ctx); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -3,6 +3,8 @@ module gingersnap
 go 1.21.1
 
 require (
+	github.com/alecthomas/chroma/v2 v2.2.0
+	github.com/andybalholm/brotli v1.1.0
 	github.com/fsnotify/fsnotify v1.6.0
 	github.com/yuin/goldmark v1.5.6
 	github.com/yuin/goldmark-highlighting/v2 v2.0.0-20230729083705-37449abec8cc
@@ -11,7 +13,6 @@ require (
 
 require (
 	github.com/BurntSushi/toml v1.2.1 // indirect
-	github.com/alecthomas/chroma/v2 v2.2.0 // indirect
 	github.com/dlclark/regexp2 v1.7.0 // indirect
 	golang.org/x/sys v0.12.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
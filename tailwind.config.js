@@ -1,5 +1,27 @@
 module.exports = {
     content: ['app/assets/templates/**/*.html', 'app/assets/**/*.go'],
+
+    // The common utilities are always generated, without their variants,
+    // so that the classes of the user's templates and posts are styled
+    // in dev. The export purges the rules which are not used.
+    safelist: [
+        {pattern: /^(static|fixed|absolute|relative|sticky|visible|invisible|(not-)?sr-only)$/},
+        {pattern: /^(inset(-[xy])?|top|right|bottom|left|z|order|col-span|row-span|float)-/},
+        {pattern: /^-?m[xytrbl]?-/},
+        {pattern: /^p[xytrbl]?-/},
+        {pattern: /^(block|inline|inline-block|flex|inline-flex|table|grid|inline-grid|contents|list-item|hidden)$/},
+        {pattern: /^(box|aspect|h|max-h|min-h|w|min-w|max-w)-/},
+        {pattern: /^(flex|shrink|grow|table|cursor|select|list|grid-cols|grid-rows)(-|$)/},
+        {pattern: /^(content|items|justify|self|gap(-[xy])?|space-[xy])-/},
+        {pattern: /^(overflow(-[xy])?|whitespace|break)-|^(truncate|text-ellipsis|text-clip)$/},
+        {pattern: /^(rounded|border)(-|$)/},
+        {pattern: /^(bg|text)-(inherit|current|transparent|black|white|(slate|gray|zinc|neutral|stone|red|orange|amber|yellow|lime|green|emerald|teal|cyan|sky|blue|indigo|violet|purple|fuchsia|pink|rose)-\d+)$/},
+        {pattern: /^(object|align|font|leading|tracking|underline-offset)-/},
+        {pattern: /^text-(left|center|right|justify|start|end|xs|sm|base|lg|\d?xl)$/},
+        {pattern: /^(uppercase|lowercase|capitalize|normal-case|italic|not-italic|underline|overline|line-through|no-underline|(subpixel-)?antialiased)$/},
+        {pattern: /^(opacity|shadow|transition|duration|ease)(-|$)/},
+    ],
+
     theme: {extend: {}},
     plugins: [],
 }